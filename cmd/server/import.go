@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"insider-assessment/internal/service"
+	"os"
+)
+
+// runImport handles the import subcommand:
+//
+//	server import -file recipients.csv [-format csv] [-template "Hi {{name}}"] [-errors report.csv]
+func runImport(importer *service.ImportService, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("file", "", "path to a CSV or NDJSON file")
+	format := fs.String("format", "", "csv or ndjson (detected from the file extension when empty)")
+	tmpl := fs.String("template", "", "content template with {{variable}} placeholders")
+	report := fs.String("errors", "", "write the error report to this CSV file")
+	fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		return errors.New("-file is required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	job, err := importer.Import(f, service.ImportOptions{
+		Filename: *file,
+		Format:   *format,
+		Template: *tmpl,
+	})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(job); err != nil {
+		return err
+	}
+
+	if *report != "" && job.FailedRows > 0 {
+		rowErrs, err := importer.Jobs.GetErrors(job.ID)
+		if err != nil {
+			return err
+		}
+		out, err := os.Create(*report)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		if err := service.WriteImportErrorReport(out, rowErrs); err != nil {
+			return err
+		}
+	}
+
+	if job.Error != "" {
+		return fmt.Errorf("import %s failed: %s", job.ID, job.Error)
+	}
+	return nil
+}
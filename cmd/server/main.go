@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"insider-assessment/internal/config"
 	"insider-assessment/internal/handler"
 	"insider-assessment/internal/model"
@@ -9,7 +10,11 @@ import (
 	"insider-assessment/internal/service"
 	"insider-assessment/pkg/database"
 	"insider-assessment/pkg/logger"
+	"insider-assessment/pkg/metrics"
+	"insider-assessment/pkg/phone"
+	"insider-assessment/pkg/tracing"
 	"log/slog"
+	"os"
 
 	_ "insider-assessment/docs"
 
@@ -27,6 +32,18 @@ func main() {
 
 	// load config
 	cfg := config.Load()
+	if err := phone.SetDefaultCountryCode(cfg.DefaultCountryCode); err != nil {
+		slog.Error("invalid default country code", "error", err)
+		panic(err)
+	}
+
+	// initialize tracing
+	shutdownTracing, err := tracing.Init(cfg)
+	if err != nil {
+		slog.Error("tracing initialization failed", "error", err)
+		panic(err)
+	}
+	defer shutdownTracing(context.Background())
 
 	// initialize db
 	db, err := database.NewPostgresDB(cfg)
@@ -35,11 +52,18 @@ func main() {
 		panic(err)
 	}
 
+	if err := tracing.RegisterGORM(db); err != nil {
+		slog.Error("failed to register GORM tracing", "error", err)
+	}
+
 	// auto-migrate db
-	if err := db.AutoMigrate(&model.Message{}); err != nil {
+	if err := db.AutoMigrate(&model.Message{}, &model.ImportJob{}, &model.ImportRowError{}, &model.Campaign{}, &model.StatusChange{}, &model.SchedulerState{}, &model.EventSubscription{}, &model.OutboxEvent{}, &model.Suppression{}, &model.InboundMessage{}, &model.Contact{}, &model.Segment{}); err != nil {
 		slog.Error("database migration failed", "error", err)
 	}
 
+	msgRepo := repository.NewMessageRepository(db)
+	importSvc := service.NewImportService(msgRepo, repository.NewImportRepository(db), cfg.ImportChunkSize)
+
 	// initialize redis
 	rdb, err := database.NewRedisClient(cfg)
 	if err != nil {
@@ -47,16 +71,108 @@ func main() {
 		// we don't panic here because Redis is a "Bonus" item. app can technically run without it.
 	}
 
-	// create repos and services - dependency injection
-	msgRepo := repository.NewMessageRepository(db)
+	// the queue is built before dispatching subcommands so imported messages are announced to it
+	queue, err := newQueue(cfg, rdb, msgRepo)
+	if err != nil {
+		slog.Error("invalid queue configuration", "error", err)
+		panic(err)
+	}
+	importSvc.Queue = queue
+
+	// `server import ...` runs a one-off file import instead of the HTTP server
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(importSvc, os.Args[2:]); err != nil {
+			slog.Error("import failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// create services - dependency injection
 	senderSvc := service.NewWorkerService(msgRepo, rdb, cfg)
-	scheduler := service.NewScheduler(senderSvc, cfg)
+	senderSvc.Queue = queue
+	senderSvc.Updates = service.NewBroadcaster(cfg.StreamHistorySize)
+	if rdb != nil {
+		// live updates reach the clients of every replica, not only the one that made them
+		if err := senderSvc.Updates.Connect(context.Background(), rdb, cfg.StreamChannel); err != nil {
+			slog.Warn("failed to subscribe to live updates, streams only show this replica's changes", "error", err)
+		}
+		defer senderSvc.Updates.Close()
+	}
+	// messages stranded in PROCESSING by a crash go back to the queue
+	reaper := service.NewLeaseReaper(msgRepo, cfg.ClaimLease)
+	reaper.Queue, reaper.Updates = senderSvc.Queue, senderSvc.Updates
+	reaper.Run()
+	defer reaper.Close()
+
+	suppressions := service.NewSuppressionService(repository.NewSuppressionRepository(db), cfg.OptOutKeywords, cfg.OptInKeywords)
+	senderSvc.Suppressions = suppressions
+
+	// lifecycle events are written to the outbox with the change they describe; the relay
+	// publishes them to Redis Pub/Sub and the registered endpoints
+	events := service.NewEventPublisher(rdb, cfg.EventsChannel, repository.NewEventSubscriptionRepository(db))
+	relay := service.NewOutboxRelay(repository.NewOutboxRepository(db), events,
+		cfg.OutboxPollInterval, cfg.EventWebhookMaxAttempts, cfg.EventRetryBackoff, cfg.OutboxRetention)
+	relay.Run()
+	defer relay.Close()
+
+	if senderSvc.QuietHours, err = service.ParseQuietHours(cfg.QuietHours, cfg.DefaultRecipientTimezone); err != nil {
+		slog.Error("invalid quiet hours configuration", "error", err)
+		panic(err)
+	}
+	scheduler, err := service.NewScheduler(senderSvc, cfg)
+	if err != nil {
+		slog.Error("invalid scheduler configuration", "error", err)
+		panic(err)
+	}
+	scheduler.State = repository.NewSchedulerStateRepository(db)
+
+	// only the replica holding the advisory lock sends
+	elector := service.NewLeaderElector(
+		repository.NewAdvisoryLock(db, repository.SchedulerLockKey, cfg.InstanceID),
+		cfg.InstanceID, cfg.LeaderCheckInterval)
+	elector.Run()
+	defer elector.Close()
+	scheduler.Elector = elector
+
+	// start the ticker; it only sends while the persisted state says running
+	scheduler.Run()
 
-	// start the scheduler
-	scheduler.Start()
+	// gauges computed at scrape time
+	statsRepo := repository.NewStatsRepository(db)
+	metrics.RegisterGauge("messages_pending", "Messages waiting to be sent.", func() float64 {
+		count, err := statsRepo.CountPending()
+		if err != nil {
+			slog.Error("failed to count pending messages", "error", err)
+			return -1
+		}
+		return float64(count)
+	})
+	metrics.RegisterGauge("scheduler_leader", "1 when this instance is the scheduler leader.", func() float64 {
+		if elector.IsLeader() {
+			return 1
+		}
+		return 0
+	})
+	metrics.RegisterGauge("scheduler_running", "1 when the scheduler is running.", func() float64 {
+		if scheduler.Running() {
+			return 1
+		}
+		return 0
+	})
 
 	// HTTP handler Setup
 	h := handler.NewHandler(scheduler, msgRepo)
+	h.Queue = senderSvc.Queue
+	h.Importer = importSvc
+	h.Campaigns = service.NewCampaignService(repository.NewCampaignRepository(db))
+	h.Events = events
+	h.Suppressions = suppressions
+	h.Inbound = service.NewInboundService(repository.NewInboundRepository(db), suppressions)
+	h.Contacts = service.NewContactService(repository.NewContactRepository(db), repository.NewSegmentRepository(db))
+	h.Contacts.Suppressions = suppressions
+	h.Contacts.MaxSegmentSize = cfg.SegmentSendLimit
+	h.Stats = service.NewStatsService(statsRepo, rdb, cfg.StatsCacheTTL)
 
 	// router setup
 	r := gin.Default()
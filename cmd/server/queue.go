@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"insider-assessment/internal/config"
+	"insider-assessment/internal/repository"
+	"insider-assessment/internal/service"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// newQueue builds the queue backend selected by QUEUE_BACKEND
+func newQueue(cfg *config.Config, rdb *redis.Client, repo repository.MessageRepository) (service.Queue, error) {
+	switch cfg.QueueBackend {
+	case service.QueueDB:
+		return service.NewDBQueue(repo), nil
+	case service.QueueRedis:
+		if rdb == nil {
+			return nil, errors.New("QUEUE_BACKEND=redis requires a reachable Redis")
+		}
+		q := service.NewStreamQueue(rdb, repo, cfg.QueueStream, cfg.InstanceID, cfg.QueueClaimTimeout)
+		q.Lease, q.PollInterval = cfg.ClaimLease, cfg.QueuePollInterval
+		if err := q.Init(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to create the stream consumer group: %w", err)
+		}
+		return q, nil
+	}
+	return nil, fmt.Errorf("unknown QUEUE_BACKEND %q, expected %s or %s", cfg.QueueBackend, service.QueueDB, service.QueueRedis)
+}
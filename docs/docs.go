@@ -15,19 +15,77 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
+        "/campaigns": {
+            "post": {
+                "description": "Validates the template against every audience entry and stores the campaign as DRAFT. Nothing is sent until it is launched.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaigns"
+                ],
+                "summary": "Create a campaign",
+                "parameters": [
+                    {
+                        "description": "Campaign",
+                        "name": "campaign",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateCampaignRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Campaign"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/campaigns/{id}": {
             "get": {
-                "description": "Returns 200 OK if the server is running",
+                "description": "Progress counts are computed from the campaign's messages.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "Campaigns"
+                ],
+                "summary": "Get a campaign with its progress",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Health check endpoint",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.CampaignView"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -38,52 +96,229 @@ const docTemplate = `{
                 }
             }
         },
-        "/messages": {
+        "/campaigns/{id}/cancel": {
             "post": {
-                "consumes": [
+                "description": "Pending messages of a cancelled campaign are never sent.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Campaigns"
+                ],
+                "summary": "Cancel a campaign",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Campaign"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/campaigns/{id}/launch": {
+            "post": {
+                "description": "Fans a DRAFT campaign out into pending messages, or resumes a PAUSED one. Messages are held until scheduled_at.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Messages"
+                    "Campaigns"
                 ],
-                "summary": "Add a new message (Test Helper)",
+                "summary": "Launch or resume a campaign",
                 "parameters": [
                     {
-                        "description": "Message Content",
-                        "name": "message",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.CreateMessageRequest"
+                            "$ref": "#/definitions/model.Campaign"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
+                }
+            }
+        },
+        "/campaigns/{id}/pause": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaigns"
+                ],
+                "summary": "Pause a running campaign",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Message"
+                            "$ref": "#/definitions/model.Campaign"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/messages/cache": {
+        "/contacts": {
             "get": {
-                "description": "Retrieves all messages currently stored in Redis cache.",
+                "description": "Ordered by number and paginated with next_cursor.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Messages"
+                    "Contacts"
+                ],
+                "summary": "List contacts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all cached messages",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ContactPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "The number is normalized and must be unique. Attributes are free-form and can be matched by segments and used as template placeholders.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Contacts"
+                ],
+                "summary": "Create a contact",
+                "parameters": [
+                    {
+                        "description": "Contact",
+                        "name": "contact",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ContactRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Contact"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -94,41 +329,129 @@ const docTemplate = `{
                 }
             }
         },
-        "/sent-messages": {
+        "/contacts/{id}": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Messages"
+                    "Contacts"
+                ],
+                "summary": "Get a contact",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Contact ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get list of sent messages",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Message"
+                            "$ref": "#/definitions/model.Contact"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     }
                 }
-            }
-        },
-        "/start": {
-            "post": {
-                "description": "Resumes the background ticker that sends messages every 2 minutes.",
+            },
+            "put": {
+                "description": "Every field is replaced, attributes included.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Control"
+                    "Contacts"
+                ],
+                "summary": "Replace a contact",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Contact ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Contact",
+                        "name": "contact",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ContactRequest"
+                        }
+                    }
                 ],
-                "summary": "Start the automatic message sender",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Contact"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Another contact has the number",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Messages already created for the contact are kept.",
+                "tags": [
+                    "Contacts"
+                ],
+                "summary": "Delete a contact",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Contact ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -139,19 +462,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/stop": {
-            "post": {
-                "description": "Pauses the background ticker.",
+        "/conversations/{phone}": {
+            "get": {
+                "description": "Messages sent to the number and its replies, newest first. Entries are paginated with next_cursor.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Control"
+                    "Inbound"
+                ],
+                "summary": "Two-way thread with a number",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Phone number",
+                        "name": "phone",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Stop the automatic message sender",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.Conversation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -161,45 +511,2128 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handler.CreateMessageRequest": {
-            "type": "object",
-            "required": [
-                "content",
-                "to"
-            ],
-            "properties": {
+        },
+        "/event-subscriptions": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "List the registered event endpoints",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.EventSubscription"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Events (` + "`" + `message.created` + "`" + `, ` + "`" + `message.sent` + "`" + `, ` + "`" + `message.failed` + "`" + `) are POSTed as JSON with ` + "`" + `X-Event-Id` + "`" + ` and ` + "`" + `X-Event-Type` + "`" + ` headers. Events are delivered at least once; failed deliveries are retried with exponential backoff.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Register an endpoint for lifecycle events",
+                "parameters": [
+                    {
+                        "description": "Subscription",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.EventSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/event-subscriptions/{id}": {
+            "delete": {
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Remove an event endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Returns 200 OK if the server is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Health check endpoint",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/imports": {
+            "post": {
+                "description": "Streams the uploaded file row by row. Each row needs a ` + "`" + `to` + "`" + ` column and either a ` + "`" + `content` + "`" + ` column or the variables used by ` + "`" + `template` + "`" + `.\nThe ` + "`" + `format` + "`" + ` and ` + "`" + `template` + "`" + ` fields must be sent before the ` + "`" + `file` + "`" + ` part.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Import messages from a CSV or NDJSON file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or ndjson (detected from the file extension when omitted)",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Content template with {{variable}} placeholders",
+                        "name": "template",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Recipients file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/imports/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Get an import job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/imports/{id}/errors": {
+            "get": {
+                "description": "Returns the rejected rows as CSV (row, to, reason).",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Imports"
+                ],
+                "summary": "Download the error report of an import",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/inbound": {
+            "post": {
+                "description": "Called by the SMS provider for every inbound (mobile-originated) message. The reply is stored and linked to the last message sent to the number. A reply consisting of an opt-out keyword (STOP, UNSUBSCRIBE, ...) suppresses the sender, an opt-in keyword (START, UNSTOP) lifts the suppression.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Inbound"
+                ],
+                "summary": "Receive a reply from a recipient",
+                "parameters": [
+                    {
+                        "description": "Inbound message",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.InboundMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.InboundMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/messages": {
+            "get": {
+                "description": "Filters messages and paginates with a keyset cursor ordered by created_at. Times are RFC3339.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Query messages",
+                "parameters": [
+                    {
+                        "enum": [
+                            "PENDING",
+                            "PROCESSING",
+                            "SENT",
+                            "FAILED",
+                            "CANCELLED",
+                            "SUPPRESSED",
+                            "DUPLICATE"
+                        ],
+                        "type": "string",
+                        "description": "Message status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recipient, in any format that normalizes to E.164",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "campaign_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent at or after",
+                        "name": "sent_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent before",
+                        "name": "sent_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MessagePage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Give either ` + "`" + `to` + "`" + ` or ` + "`" + `segment_id` + "`" + `. A segment is expanded into one PENDING message per matching contact, with the contact's name, phone, locale and attributes filling the content's {{placeholders}} and its timezone used for quiet hours; opted-out contacts are left out. A segment matching more than SEGMENT_SEND_LIMIT contacts is refused with 400, one matching nobody is accepted with no messages.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Add a new message (Test Helper)",
+                "parameters": [
+                    {
+                        "description": "Message Content",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Sent to a single recipient",
+                        "schema": {
+                            "$ref": "#/definitions/model.Message"
+                        }
+                    },
+                    "202": {
+                        "description": "Sent to a segment",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SegmentSendResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid message, or the segment is too large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "The segment doesn't exist",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "The recipient opted out",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/messages/cache": {
+            "get": {
+                "description": "Walks the Redis cache of sent messages with SCAN, so large caches don't block Redis.\nPass ` + "`" + `next_cursor` + "`" + ` back as ` + "`" + `cursor` + "`" + ` until it is empty. ` + "`" + `limit` + "`" + ` is a hint to Redis: a page may hold more or fewer entries, even none, before the scan completes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "List cached deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Keys examined per page (default 50, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.CachePage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/messages/cache/{remote_id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Look up a cached delivery by the provider's message ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "messageId returned by the webhook",
+                        "name": "remote_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.CachedMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/messages/retry": {
+            "post": {
+                "description": "Resets every FAILED message matching the filter to PENDING, e.g. after a webhook outage. Only status FAILED can be retried. At least one of failed_after, failed_before and campaign_id is required; send ` + "`" + `\"all\": true` + "`" + ` instead to retry every failed message.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Retry failed messages in bulk",
+                "parameters": [
+                    {
+                        "description": "Filter",
+                        "name": "filter",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RetryMessagesRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Who triggered the retry",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer",
+                                "format": "int64"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/messages/stream": {
+            "get": {
+                "description": "Server-Sent Events stream of status transitions: messages created, cancelled and retried through the API and every change the workers make, on all replicas when Redis is available. Each ` + "`" + `status` + "`" + ` event carries a StatusUpdate and its ` + "`" + `id` + "`" + `; a client reconnecting with ` + "`" + `Last-Event-ID` + "`" + ` first receives the updates it missed, as far as they are still kept in memory. Idle streams send a comment every 15 seconds.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Stream live status changes",
+                "parameters": [
+                    {
+                        "enum": [
+                            "PENDING",
+                            "PROCESSING",
+                            "SENT",
+                            "FAILED",
+                            "CANCELLED",
+                            "SUPPRESSED",
+                            "DUPLICATE"
+                        ],
+                        "type": "string",
+                        "description": "New status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recipient, in any format that normalizes to E.164",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "campaign_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.StatusUpdate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/messages/{id}": {
+            "get": {
+                "description": "The timeline starts with the creation and lists every status change with its time and actor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get a message with its lifecycle timeline",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MessageDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/messages/{id}/cache": {
+            "get": {
+                "description": "Returns the record of the message's last delivery while it is cached (REDIS_TTL).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Look up the cached delivery of a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.CachedMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/messages/{id}/cancel": {
+            "post": {
+                "description": "Moves a PENDING message to CANCELLED. Messages already claimed by the worker or finished cannot be cancelled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Cancel a pending message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Who cancelled the message",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/messages/{id}/retry": {
+            "post": {
+                "description": "Moves a FAILED message back to PENDING so the worker sends it again. The caller is recorded from the X-Actor header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Retry a failed message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Who triggered the retry",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Message"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduler": {
+            "get": {
+                "description": "Running state, current settings, the last tick with its result and the next planned tick.\n` + "`" + `leadership` + "`" + ` shows whether this replica is the leader that sends, and which instance is.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Control"
+                ],
+                "summary": "Get the scheduler state",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.SchedulerStatus"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Omitted fields keep their value and the next tick is re-planned right away.\n` + "`" + `schedule` + "`" + ` is a five-field cron expression (e.g. ` + "`" + `*/5 * * * *` + "`" + `) that replaces the interval. ` + "`" + `window` + "`" + ` limits sending to e.g. ` + "`" + `Mon-Fri 09:00-21:00 Europe/Istanbul` + "`" + `; messages outside it stay PENDING.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Control"
+                ],
+                "summary": "Change the scheduler settings at runtime",
+                "parameters": [
+                    {
+                        "description": "Settings",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateSchedulerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.SchedulerStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduler/run": {
+            "post": {
+                "description": "Claims and sends one batch without waiting for the next tick and returns its result. Quiet hours still apply.\nThe body is optional; ` + "`" + `batch_size` + "`" + ` overrides the configured size for this run only. Returns 409 while the scheduler is stopped, outside the send window or while a batch is in progress on this instance, and 503 on a follower replica or when the scheduler state can't be read.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Control"
+                ],
+                "summary": "Process one batch immediately",
+                "parameters": [
+                    {
+                        "description": "Run options",
+                        "name": "run",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RunSchedulerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.BatchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/segments": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Segments"
+                ],
+                "summary": "List segments",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Segment"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "A segment is a saved filter over contacts; a contact belongs to it when every condition holds. Fields are ` + "`" + `locale` + "`" + `, ` + "`" + `timezone` + "`" + ` or ` + "`" + `attributes.\u003ckey\u003e` + "`" + `; operators are ` + "`" + `eq` + "`" + `, ` + "`" + `ne` + "`" + `, ` + "`" + `in` + "`" + ` (value is a list) and ` + "`" + `exists` + "`" + ` (no value).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Segments"
+                ],
+                "summary": "Create a segment",
+                "parameters": [
+                    {
+                        "description": "Segment",
+                        "name": "segment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SegmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Segment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/segments/{id}": {
+            "get": {
+                "description": "Includes the number of contacts the segment matches right now.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Segments"
+                ],
+                "summary": "Get a segment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Segment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.SegmentView"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Segments"
+                ],
+                "summary": "Replace a segment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Segment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Segment",
+                        "name": "segment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SegmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Segment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "The contacts it matched are kept.",
+                "tags": [
+                    "Segments"
+                ],
+                "summary": "Delete a segment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Segment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/sent-messages": {
+            "get": {
+                "description": "Oldest first, paginated. Pass the X-Next-Cursor response header back as ` + "`" + `cursor` + "`" + ` to fetch the next page. Takes the filters of GET /messages; status can only be SENT.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get list of sent messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipient, in any format that normalizes to E.164",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "campaign_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent at or after",
+                        "name": "sent_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sent before",
+                        "name": "sent_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc (default) or desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Message"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, absent on the last page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/start": {
+            "post": {
+                "description": "Resumes the background ticker that sends messages every 2 minutes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Control"
+                ],
+                "summary": "Start the automatic message sender",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/stats": {
+            "get": {
+                "description": "Counts by status, oldest pending age, sends in the last minute/hour, failure rate and webhook latency percentiles over the last hour. Cached for a few seconds.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Queue statistics and throughput",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.Stats"
+                        }
+                    }
+                }
+            }
+        },
+        "/stop": {
+            "post": {
+                "description": "Pauses the background ticker.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Control"
+                ],
+                "summary": "Stop the automatic message sender",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/suppressions": {
+            "get": {
+                "description": "Ordered by number and paginated with next_cursor.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Suppressions"
+                ],
+                "summary": "List suppressed recipients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SuppressionPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Opted-out numbers are rejected by POST /messages, and queued messages to them end as SUPPRESSED instead of being sent. Numbers are normalized, so formatting doesn't matter.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Suppressions"
+                ],
+                "summary": "Suppress a recipient",
+                "parameters": [
+                    {
+                        "description": "Suppression",
+                        "name": "suppression",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateSuppressionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Suppression"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/suppressions/{phone}": {
+            "delete": {
+                "tags": [
+                    "Suppressions"
+                ],
+                "summary": "Lift a suppression",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Suppressed number",
+                        "name": "phone",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.ContactPage": {
+            "type": "object",
+            "properties": {
+                "contacts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Contact"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.ContactRequest": {
+            "type": "object",
+            "required": [
+                "phone"
+            ],
+            "properties": {
+                "attributes": {
+                    "type": "object"
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "tr-TR"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Ayşe"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+905551112233"
+                },
+                "timezone": {
+                    "description": "IANA name; inferred from the number's country code when empty",
+                    "type": "string",
+                    "example": "Europe/Istanbul"
+                }
+            }
+        },
+        "handler.CreateCampaignRequest": {
+            "type": "object",
+            "required": [
+                "audience",
+                "name",
+                "template"
+            ],
+            "properties": {
+                "audience": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CampaignRecipient"
+                    }
+                },
+                "category": {
+                    "description": "copied to every message; defaults to marketing",
+                    "type": "string",
+                    "enum": [
+                        "transactional",
+                        "marketing"
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scheduled_at": {
+                    "type": "string"
+                },
+                "template": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.CreateMessageRequest": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "category": {
+                    "description": "quiet hours are configured per category; defaults to marketing",
+                    "type": "string",
+                    "enum": [
+                        "transactional",
+                        "marketing"
+                    ]
+                },
+                "content": {
+                    "description": "with segment_id, {{placeholders}} are filled from each contact",
+                    "type": "string"
+                },
+                "segment_id": {
+                    "description": "sends one message to every contact of the segment instead",
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "IANA name; inferred from the number's country code when empty",
+                    "type": "string",
+                    "example": "Europe/Istanbul"
+                },
+                "to": {
+                    "description": "required unless segment_id is given",
+                    "type": "string"
+                }
+            }
+        },
+        "handler.CreateSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "description": "empty subscribes to every event",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.EventType"
+                    },
+                    "example": [
+                        "message.sent",
+                        "message.failed"
+                    ]
+                },
+                "secret": {
+                    "description": "deliveries carry X-Event-Signature: sha256=HMAC(secret, body)",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/sms"
+                }
+            }
+        },
+        "handler.CreateSuppressionRequest": {
+            "type": "object",
+            "required": [
+                "phone"
+            ],
+            "properties": {
+                "phone": {
+                    "type": "string",
+                    "example": "+905551112233"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "asked support to stop"
+                }
+            }
+        },
+        "handler.InboundMessageRequest": {
+            "type": "object",
+            "required": [
+                "from"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "STOP"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "+905551112233"
+                },
+                "remote_id": {
+                    "description": "provider's ID of the reply; a redelivery with the same ID returns the stored reply",
+                    "type": "string",
+                    "example": "mo-8f14e45f"
+                }
+            }
+        },
+        "handler.InboundMessageResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "opted_out",
+                        "opted_in",
+                        "none"
+                    ]
+                },
+                "message": {
+                    "$ref": "#/definitions/model.InboundMessage"
+                }
+            }
+        },
+        "handler.MessageDetail": {
+            "type": "object",
+            "properties": {
+                "campaign_id": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "claimed_at": {
+                    "description": "start of the lease of the worker holding it in PROCESSING",
+                    "type": "string"
+                },
                 "content": {
                     "type": "string"
                 },
+                "created_at": {
+                    "type": "string"
+                },
+                "duplicate_of": {
+                    "description": "the sent message a DUPLICATE repeats",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "not_before": {
+                    "description": "the worker doesn't claim the message before this time",
+                    "type": "string"
+                },
+                "retried_at": {
+                    "type": "string"
+                },
+                "retried_by": {
+                    "type": "string"
+                },
+                "retry_count": {
+                    "type": "integer"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.MessageStatus"
+                },
+                "timeline": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.StatusChange"
+                    }
+                },
+                "timezone": {
+                    "description": "recipient's IANA timezone, inferred from the number when empty",
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "trace_id": {
+                    "description": "trace of the API call that created the message",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.MessagePage": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Message"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.RetryMessagesRequest": {
+            "type": "object",
+            "properties": {
+                "all": {
+                    "description": "required to retry every FAILED message when no filter is given",
+                    "type": "boolean"
+                },
+                "campaign_id": {
+                    "type": "string"
+                },
+                "failed_after": {
+                    "type": "string"
+                },
+                "failed_before": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.MessageStatus"
+                }
+            }
+        },
+        "handler.RunSchedulerRequest": {
+            "type": "object",
+            "properties": {
+                "batch_size": {
+                    "description": "overrides the configured batch size for this run",
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "handler.SegmentRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "conditions": {
+                    "description": "all must hold; none matches every contact",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SegmentCondition"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Istanbul gold members"
+                }
+            }
+        },
+        "handler.SegmentSendResult": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "integer"
+                },
+                "segment_id": {
+                    "type": "string"
+                },
+                "suppressed": {
+                    "description": "matching contacts left out because they opted out",
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.SuppressionPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "suppressions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Suppression"
+                    }
+                }
+            }
+        },
+        "handler.UpdateSchedulerRequest": {
+            "type": "object",
+            "properties": {
+                "batch_size": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "30s"
+                },
+                "schedule": {
+                    "description": "cron expression, \"\" goes back to the interval",
+                    "type": "string",
+                    "example": "*/5 * * * *"
+                },
+                "window": {
+                    "description": "send window, \"\" removes it",
+                    "type": "string",
+                    "example": "Mon-Fri 09:00-21:00 Europe/Istanbul"
+                }
+            }
+        },
+        "model.Campaign": {
+            "type": "object",
+            "properties": {
+                "audience": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CampaignRecipient"
+                    }
+                },
+                "category": {
+                    "description": "copied to every message",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "launched_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scheduled_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.CampaignStatus"
+                },
+                "template": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CampaignRecipient": {
+            "type": "object",
+            "properties": {
+                "timezone": {
+                    "description": "IANA name; inferred from the number when empty",
+                    "type": "string"
+                },
                 "to": {
                     "type": "string"
+                },
+                "vars": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.CampaignStatus": {
+            "type": "string",
+            "enum": [
+                "DRAFT",
+                "RUNNING",
+                "PAUSED",
+                "CANCELLED"
+            ],
+            "x-enum-varnames": [
+                "CampaignDraft",
+                "CampaignRunning",
+                "CampaignPaused",
+                "CampaignCancelled"
+            ]
+        },
+        "model.Contact": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "tr-TR"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "copied to the messages sent to the contact",
+                    "type": "string",
+                    "example": "Europe/Istanbul"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.EventSubscription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "empty means every event",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.EventType"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.EventType": {
+            "type": "string",
+            "enum": [
+                "message.created",
+                "message.sent",
+                "message.failed"
+            ],
+            "x-enum-varnames": [
+                "EventMessageCreated",
+                "EventMessageSent",
+                "EventMessageFailed"
+            ]
+        },
+        "model.ImportJob": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed_rows": {
+                    "type": "integer"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "imported_rows": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ImportStatus"
+                },
+                "total_rows": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ImportStatus": {
+            "type": "string",
+            "enum": [
+                "PROCESSING",
+                "COMPLETED",
+                "FAILED"
+            ],
+            "x-enum-varnames": [
+                "ImportProcessing",
+                "ImportCompleted",
+                "ImportFailed"
+            ]
+        },
+        "model.InboundMessage": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "from": {
+                    "description": "normalized by phone.Normalize",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "in_reply_to": {
+                    "description": "the last message sent to the number before the reply",
+                    "type": "string"
+                },
+                "received_at": {
+                    "type": "string"
+                },
+                "remote_id": {
+                    "description": "provider's ID; a redelivered reply is stored once",
+                    "type": "string"
                 }
             }
         },
         "model.Message": {
             "type": "object",
             "properties": {
+                "campaign_id": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "claimed_at": {
+                    "description": "start of the lease of the worker holding it in PROCESSING",
+                    "type": "string"
+                },
                 "content": {
                     "type": "string"
                 },
-                "created_at": {
+                "created_at": {
+                    "type": "string"
+                },
+                "duplicate_of": {
+                    "description": "the sent message a DUPLICATE repeats",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "not_before": {
+                    "description": "the worker doesn't claim the message before this time",
                     "type": "string"
                 },
-                "id": {
+                "retried_at": {
                     "type": "string"
                 },
+                "retried_by": {
+                    "type": "string"
+                },
+                "retry_count": {
+                    "type": "integer"
+                },
                 "sent_at": {
                     "type": "string"
                 },
                 "status": {
                     "$ref": "#/definitions/model.MessageStatus"
                 },
+                "timezone": {
+                    "description": "recipient's IANA timezone, inferred from the number when empty",
+                    "type": "string"
+                },
                 "to": {
                     "type": "string"
                 },
+                "trace_id": {
+                    "description": "trace of the API call that created the message",
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 }
@@ -209,14 +2642,458 @@ const docTemplate = `{
             "type": "string",
             "enum": [
                 "PENDING",
+                "PROCESSING",
                 "SENT",
-                "FAILED"
+                "FAILED",
+                "CANCELLED",
+                "SUPPRESSED",
+                "DUPLICATE"
+            ],
+            "x-enum-comments": {
+                "StatusDuplicate": "the same content already went to the recipient within the dedupe window",
+                "StatusProcessing": "claimed by a worker, webhook call in flight",
+                "StatusSuppressed": "the recipient opted out before it was sent"
+            },
+            "x-enum-descriptions": [
+                "",
+                "claimed by a worker, webhook call in flight",
+                "",
+                "",
+                "",
+                "the recipient opted out before it was sent",
+                "the same content already went to the recipient within the dedupe window"
             ],
             "x-enum-varnames": [
                 "StatusPending",
+                "StatusProcessing",
                 "StatusSent",
-                "StatusFailed"
+                "StatusFailed",
+                "StatusCancelled",
+                "StatusSuppressed",
+                "StatusDuplicate"
             ]
+        },
+        "model.Segment": {
+            "type": "object",
+            "properties": {
+                "conditions": {
+                    "description": "empty matches every contact",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SegmentCondition"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.SegmentCondition": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "attributes.plan"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "eq",
+                        "ne",
+                        "in",
+                        "exists"
+                    ]
+                },
+                "value": {
+                    "type": "string",
+                    "example": "premium"
+                }
+            }
+        },
+        "model.StatusChange": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "from": {
+                    "$ref": "#/definitions/model.MessageStatus"
+                },
+                "latency_ms": {
+                    "description": "webhook round trip for sent/failed",
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.MessageStatus"
+                }
+            }
+        },
+        "model.Suppression": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.Stats": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "failed_last_hour": {
+                    "type": "integer"
+                },
+                "failure_rate": {
+                    "description": "failed / (sent + failed) over the last hour",
+                    "type": "number"
+                },
+                "generated_at": {
+                    "type": "string"
+                },
+                "oldest_pending_age_seconds": {
+                    "type": "number"
+                },
+                "sent_last_hour": {
+                    "type": "integer"
+                },
+                "sent_last_minute": {
+                    "type": "integer"
+                },
+                "webhook_latency_p50_ms": {
+                    "type": "number"
+                },
+                "webhook_latency_p95_ms": {
+                    "type": "number"
+                }
+            }
+        },
+        "service.BatchResult": {
+            "type": "object",
+            "properties": {
+                "claimed": {
+                    "type": "integer"
+                },
+                "deferred": {
+                    "type": "integer"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "sent": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "suppressed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.CachePage": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.CachedMessage"
+                    }
+                },
+                "next_cursor": {
+                    "description": "empty once the scan is complete",
+                    "type": "string"
+                }
+            }
+        },
+        "service.CachedMessage": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "description": "1 for the first send, incremented by every retry",
+                    "type": "integer"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "recipient_hash": {
+                    "type": "string"
+                },
+                "remote_id": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.CampaignProgress": {
+            "type": "object",
+            "properties": {
+                "cancelled": {
+                    "type": "integer"
+                },
+                "duplicate": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "description": "includes messages currently being sent",
+                    "type": "integer"
+                },
+                "sent": {
+                    "type": "integer"
+                },
+                "suppressed": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.CampaignView": {
+            "type": "object",
+            "properties": {
+                "audience": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CampaignRecipient"
+                    }
+                },
+                "category": {
+                    "description": "copied to every message",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "launched_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "progress": {
+                    "$ref": "#/definitions/service.CampaignProgress"
+                },
+                "scheduled_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.CampaignStatus"
+                },
+                "template": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.Conversation": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ConversationEntry"
+                    }
+                },
+                "next_cursor": {
+                    "description": "pass as cursor for older entries",
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.ConversationEntry": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "sent_at or received_at",
+                    "type": "string"
+                },
+                "campaign_id": {
+                    "description": "outbound only",
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string",
+                    "enum": [
+                        "outbound",
+                        "inbound"
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "in_reply_to": {
+                    "description": "inbound only",
+                    "type": "string"
+                }
+            }
+        },
+        "service.LeaderStatus": {
+            "type": "object",
+            "properties": {
+                "instance": {
+                    "type": "string"
+                },
+                "leader": {
+                    "type": "boolean"
+                },
+                "leader_instance": {
+                    "type": "string"
+                },
+                "leader_since": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.SchedulerStatus": {
+            "type": "object",
+            "properties": {
+                "batch_size": {
+                    "type": "integer"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "description": "who last started or stopped the scheduler",
+                    "type": "string"
+                },
+                "in_window": {
+                    "type": "boolean"
+                },
+                "interval": {
+                    "type": "string"
+                },
+                "last_result": {
+                    "$ref": "#/definitions/service.BatchResult"
+                },
+                "last_tick": {
+                    "type": "string"
+                },
+                "leadership": {
+                    "$ref": "#/definitions/service.LeaderStatus"
+                },
+                "next_tick": {
+                    "type": "string"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "schedule": {
+                    "type": "string"
+                },
+                "window": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.SegmentView": {
+            "type": "object",
+            "properties": {
+                "conditions": {
+                    "description": "empty matches every contact",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SegmentCondition"
+                    }
+                },
+                "contacts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.StatusUpdate": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "campaign_id": {
+                    "type": "string"
+                },
+                "from": {
+                    "$ref": "#/definitions/model.MessageStatus"
+                },
+                "id": {
+                    "description": "increases with every update, across restarts too",
+                    "type": "integer"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.MessageStatus"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
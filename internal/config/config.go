@@ -17,10 +17,49 @@ type Config struct {
 	DBPort          string
 	RedisAddr       string
 	WebhookUrl      string
+	WebhookTimeout  time.Duration
 	ServerPort      string
 	WorkerBatchSize int
 	WorkerInterval  time.Duration
+	WorkerSchedule  string // cron expression, replaces WorkerInterval when set
+	SendWindow      string // e.g. "Mon-Fri 09:00-21:00 Europe/Istanbul"
 	RedisTTL        time.Duration
+	ImportChunkSize int
+	StatsCacheTTL   time.Duration
+
+	RecipientHashSecret string // keys the recipient hashes in the delivery cache
+	SegmentSendLimit    int    // most contacts a single segment send may reach
+
+	QuietHours               string // e.g. "marketing=21:00-09:00", in the recipient's timezone
+	DefaultRecipientTimezone string
+	DefaultCountryCode       string // calling code assumed for numbers in national format, e.g. "90"
+
+	ServiceName        string
+	TracingExporter    string // none, stdout or otlp
+	TracingSampleRatio float64
+
+	InstanceID          string
+	LeaderCheckInterval time.Duration
+
+	QueueBackend      string // db or redis
+	QueueStream       string
+	QueueClaimTimeout time.Duration
+	QueuePollInterval time.Duration // how often the redis backend polls Postgres for unannounced messages
+	ClaimLease        time.Duration // PROCESSING messages older than this go back to PENDING
+
+	EventsChannel           string
+	EventWebhookMaxAttempts int
+	EventRetryBackoff       time.Duration
+	OutboxPollInterval      time.Duration
+	OutboxRetention         time.Duration
+
+	StreamHistorySize int
+	StreamChannel     string // Redis Pub/Sub channel that carries live status updates between replicas
+
+	OptOutKeywords string
+	OptInKeywords  string
+
+	DedupeWindow time.Duration
 }
 
 func Load() *Config {
@@ -36,11 +75,58 @@ func Load() *Config {
 		DBPort:          getEnv("DB_PORT", "5432"),
 		RedisAddr:       getEnv("REDIS_ADDR", "localhost:6379"),
 		WebhookUrl:      getEnv("WEBHOOK_URL", ""),
+		WebhookTimeout:  getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
 		ServerPort:      getEnv("SERVER_PORT", "8080"),
 		WorkerBatchSize: getEnvInt("WORKER_BATCH_SIZE", 2),
 		WorkerInterval:  getEnvDuration("WORKER_INTERVAL", 2*time.Minute),
+		WorkerSchedule:  getEnv("WORKER_SCHEDULE", ""),
+		SendWindow:      getEnv("SEND_WINDOW", ""),
 		RedisTTL:        getEnvDuration("REDIS_TTL", 24*time.Hour),
+		ImportChunkSize: getEnvInt("IMPORT_CHUNK_SIZE", 500),
+		StatsCacheTTL:   getEnvDuration("STATS_CACHE_TTL", 5*time.Second),
+
+		RecipientHashSecret: getEnv("RECIPIENT_HASH_SECRET", ""),
+		SegmentSendLimit:    getEnvInt("SEGMENT_SEND_LIMIT", 10000),
+
+		QuietHours:               getEnv("QUIET_HOURS", ""),
+		DefaultRecipientTimezone: getEnv("DEFAULT_RECIPIENT_TIMEZONE", "UTC"),
+		DefaultCountryCode:       getEnv("DEFAULT_COUNTRY_CODE", ""),
+
+		ServiceName:        getEnv("OTEL_SERVICE_NAME", "insider-assessment"),
+		TracingExporter:    getEnv("TRACING_EXPORTER", "none"),
+		TracingSampleRatio: getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+
+		InstanceID:          getEnv("INSTANCE_ID", hostname()),
+		LeaderCheckInterval: getEnvDuration("LEADER_CHECK_INTERVAL", 5*time.Second),
+
+		QueueBackend:      getEnv("QUEUE_BACKEND", "db"),
+		QueueStream:       getEnv("QUEUE_STREAM", "queue:messages"),
+		QueueClaimTimeout: getEnvDuration("QUEUE_CLAIM_TIMEOUT", 5*time.Minute),
+		QueuePollInterval: getEnvDuration("QUEUE_POLL_INTERVAL", time.Minute),
+		ClaimLease:        getEnvDuration("CLAIM_LEASE", 10*time.Minute),
+
+		EventsChannel:           getEnv("EVENTS_CHANNEL", "events:messages"),
+		EventWebhookMaxAttempts: getEnvInt("EVENT_WEBHOOK_MAX_ATTEMPTS", 5),
+		EventRetryBackoff:       getEnvDuration("EVENT_RETRY_BACKOFF", 30*time.Second),
+		OutboxPollInterval:      getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetention:         getEnvDuration("OUTBOX_RETENTION", 72*time.Hour),
+
+		StreamHistorySize: getEnvInt("STREAM_HISTORY_SIZE", 1000),
+		StreamChannel:     getEnv("STREAM_CHANNEL", "updates:messages"),
+
+		OptOutKeywords: getEnv("OPT_OUT_KEYWORDS", "STOP,STOPALL,UNSUBSCRIBE,CANCEL,END,QUIT"),
+		OptInKeywords:  getEnv("OPT_IN_KEYWORDS", "START,UNSTOP"),
+
+		DedupeWindow: getEnvDuration("DEDUPE_WINDOW", 0),
+	}
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
 	}
+	return name
 }
 
 func getEnv(key, fallback string) string {
@@ -59,6 +145,15 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+func getEnvFloat(key string, fallback float64) float64 {
+	if value, ok := os.LookupEnv(key); ok {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
+
 func getEnvDuration(key string, fallback time.Duration) time.Duration {
 	if value, ok := os.LookupEnv(key); ok {
 		if d, err := time.ParseDuration(value); err == nil {
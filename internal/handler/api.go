@@ -1,17 +1,33 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"insider-assessment/internal/model"
 	"insider-assessment/internal/repository"
 	"insider-assessment/internal/service"
+	"insider-assessment/pkg/phone"
+	"insider-assessment/pkg/tracing"
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type Handler struct {
-	Scheduler *service.Scheduler
-	Repo      repository.MessageRepository
+	Scheduler    *service.Scheduler
+	Repo         repository.MessageRepository
+	Importer     *service.ImportService
+	Campaigns    *service.CampaignService
+	Stats        *service.StatsService
+	Queue        service.Queue               // optional; new and retried messages are announced to it
+	Events       *service.EventPublisher     // optional; lifecycle events and their subscriptions
+	Suppressions *service.SuppressionService // optional; opt-out list
+	Inbound      *service.InboundService     // optional; replies and conversations
+	Contacts     *service.ContactService     // optional; contacts and segments
 }
 
 func NewHandler(scheduler *service.Scheduler, repo repository.MessageRepository) *Handler {
@@ -24,9 +40,13 @@ func NewHandler(scheduler *service.Scheduler, repo repository.MessageRepository)
 // @Tags Control
 // @Produce json
 // @Success 200 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /start [post]
 func (h *Handler) StartScheduler(c *gin.Context) {
-	h.Scheduler.Start()
+	if err := h.Scheduler.Start(actorFrom(c)); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Automatic message sending started"})
 }
 
@@ -36,39 +56,189 @@ func (h *Handler) StartScheduler(c *gin.Context) {
 // @Tags Control
 // @Produce json
 // @Success 200 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /stop [post]
 func (h *Handler) StopScheduler(c *gin.Context) {
-	h.Scheduler.Stop()
+	if err := h.Scheduler.Stop(actorFrom(c)); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Automatic message sending stopped"})
 }
 
 // GetSentMessages godoc
 // @Summary Get list of sent messages
+// @Description Oldest first, paginated. Pass the X-Next-Cursor response header back as `cursor` to fetch the next page. Takes the filters of GET /messages; status can only be SENT.
 // @Tags Messages
 // @Produce json
+// @Param to query string false "Recipient, in any format that normalizes to E.164"
+// @Param campaign_id query string false "Campaign ID"
+// @Param created_after query string false "Created at or after"
+// @Param created_before query string false "Created before"
+// @Param sent_after query string false "Sent at or after"
+// @Param sent_before query string false "Sent before"
+// @Param order query string false "asc (default) or desc"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param cursor query string false "Cursor from the previous page"
 // @Success 200 {array} model.Message
+// @Failure 400 {object} map[string]string
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page, absent on the last page"
 // @Router /sent-messages [get]
 func (h *Handler) GetSentMessages(c *gin.Context) {
-	msgs, err := h.Repo.GetAllSent()
+	var query ListMessagesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := query.filter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Status != "" && filter.Status != model.StatusSent {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only SENT messages are listed here, use GET /messages"})
+		return
+	}
+	filter.Status = model.StatusSent
+
+	msgs, next, err := h.messages(c).List(filter)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+
+	if next != "" {
+		c.Header("X-Next-Cursor", next)
+	}
 	c.JSON(http.StatusOK, msgs)
 }
 
+type ListMessagesQuery struct {
+	Status        model.MessageStatus `form:"status"`
+	To            string              `form:"to"`
+	CampaignID    string              `form:"campaign_id" binding:"omitempty,uuid"`
+	CreatedAfter  *time.Time          `form:"created_after"`
+	CreatedBefore *time.Time          `form:"created_before"`
+	SentAfter     *time.Time          `form:"sent_after"`
+	SentBefore    *time.Time          `form:"sent_before"`
+	Order         string              `form:"order" binding:"omitempty,oneof=asc desc"`
+	Cursor        string              `form:"cursor"`
+	Limit         int                 `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// filter turns the bound query into a repository filter, rejecting unknown statuses and
+// recipients that aren't phone numbers
+func (q ListMessagesQuery) filter() (repository.MessageFilter, error) {
+	filter := repository.MessageFilter{
+		Status:        model.MessageStatus(strings.ToUpper(string(q.Status))),
+		CreatedAfter:  q.CreatedAfter,
+		CreatedBefore: q.CreatedBefore,
+		SentAfter:     q.SentAfter,
+		SentBefore:    q.SentBefore,
+		Descending:    q.Order == "desc",
+		Cursor:        q.Cursor,
+		Limit:         pageSize(q.Limit),
+	}
+	if q.CampaignID != "" {
+		campaignID := uuid.MustParse(q.CampaignID) // validated by binding
+		filter.CampaignID = &campaignID
+	}
+	if filter.Status != "" && !filter.Status.Valid() {
+		return filter, fmt.Errorf("unknown status %q", q.Status)
+	}
+	if q.To != "" {
+		to, err := service.NormalizeRecipient(q.To)
+		if err != nil {
+			return filter, err
+		}
+		filter.To = to
+	}
+	return filter, nil
+}
+
+type MessagePage struct {
+	Messages   []model.Message `json:"messages"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// ListMessages godoc
+// @Summary Query messages
+// @Description Filters messages and paginates with a keyset cursor ordered by created_at. Times are RFC3339.
+// @Tags Messages
+// @Produce json
+// @Param status query string false "Message status" Enums(PENDING, PROCESSING, SENT, FAILED, CANCELLED, SUPPRESSED, DUPLICATE)
+// @Param to query string false "Recipient, in any format that normalizes to E.164"
+// @Param campaign_id query string false "Campaign ID"
+// @Param created_after query string false "Created at or after"
+// @Param created_before query string false "Created before"
+// @Param sent_after query string false "Sent at or after"
+// @Param sent_before query string false "Sent before"
+// @Param order query string false "asc (default) or desc"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Success 200 {object} MessagePage
+// @Failure 400 {object} map[string]string
+// @Router /messages [get]
+func (h *Handler) ListMessages(c *gin.Context) {
+	var query ListMessagesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := query.filter()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	msgs, next, err := h.messages(c).List(filter)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if msgs == nil {
+		msgs = []model.Message{}
+	}
+	c.JSON(http.StatusOK, MessagePage{Messages: msgs, NextCursor: next})
+}
+
 type CreateMessageRequest struct {
-	To      string `json:"to" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	To        string `json:"to"`                                                 // required unless segment_id is given
+	SegmentID string `json:"segment_id,omitempty" binding:"omitempty,uuid"`      // sends one message to every contact of the segment instead
+	Content   string `json:"content" binding:"required"`                         // with segment_id, {{placeholders}} are filled from each contact
+	Category  string `json:"category,omitempty" enums:"transactional,marketing"` // quiet hours are configured per category; defaults to marketing
+	Timezone  string `json:"timezone,omitempty" example:"Europe/Istanbul"`       // IANA name; inferred from the number's country code when empty
+}
+
+type SegmentSendResult struct {
+	SegmentID  uuid.UUID `json:"segment_id"`
+	Messages   int       `json:"messages"`
+	Suppressed int       `json:"suppressed"` // matching contacts left out because they opted out
 }
 
 // AddMessage godoc
 // @Summary Add a new message (Test Helper)
+// @Description Give either `to` or `segment_id`. A segment is expanded into one PENDING message per matching contact, with the contact's name, phone, locale and attributes filling the content's {{placeholders}} and its timezone used for quiet hours; opted-out contacts are left out. A segment matching more than SEGMENT_SEND_LIMIT contacts is refused with 400, one matching nobody is accepted with no messages.
 // @Tags Messages
 // @Accept json
 // @Produce json
 // @Param message body CreateMessageRequest true "Message Content"
-// @Success 201 {object} model.Message
+// @Success 201 {object} model.Message "Sent to a single recipient"
+// @Success 202 {object} SegmentSendResult "Sent to a segment"
+// @Failure 400 {object} map[string]string "Invalid message, or the segment is too large"
+// @Failure 404 {object} map[string]string "The segment doesn't exist"
+// @Failure 422 {object} map[string]string "The recipient opted out"
 // @Router /messages [post]
 func (h *Handler) AddMessage(c *gin.Context) {
 	var req CreateMessageRequest
@@ -76,20 +246,254 @@ func (h *Handler) AddMessage(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if (req.To == "") == (req.SegmentID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of to and segment_id is required"})
+		return
+	}
+	if req.SegmentID != "" {
+		h.sendToSegment(c, req)
+		return
+	}
+	if err := service.ValidateRecipient(req.Category, req.Timezone); err != nil {
+		respondError(c, err, "")
+		return
+	}
+	if h.Suppressions != nil {
+		if err := h.Suppressions.Check(req.To); err != nil {
+			respondError(c, err, "")
+			return
+		}
+	}
 
 	msg := model.Message{
-		To:      req.To,
-		Content: req.Content,
-		Status:  model.StatusPending,
+		To:       phone.Normalize(req.To),
+		Content:  req.Content,
+		Status:   model.StatusPending,
+		Category: model.NormalizeCategory(req.Category),
+		Timezone: req.Timezone,
 	}
+	msg.TraceID, msg.SpanID, msg.TraceFlags = tracing.IDs(c.Request.Context())
 
-	if err := h.Repo.Create(&msg); err != nil {
+	if err := h.messages(c).Create(&msg); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.enqueue(c, msg.ID)
+	h.broadcast("", msg)
 	c.JSON(http.StatusCreated, msg)
 }
 
+// sendToSegment stores one message per contact of the segment in a single batch
+func (h *Handler) sendToSegment(c *gin.Context, req CreateMessageRequest) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+	if req.Timezone != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "timezone is taken from each contact when sending to a segment"})
+		return
+	}
+	if err := service.ValidateRecipient(req.Category, ""); err != nil {
+		respondError(c, err, "")
+		return
+	}
+
+	segmentID, err := uuid.Parse(req.SegmentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid segment_id"})
+		return
+	}
+	expansion, err := h.Contacts.ExpandSegment(segmentID, req.Content, req.Category)
+	if err != nil {
+		respondError(c, err, "segment not found")
+		return
+	}
+
+	msgs := expansion.Messages
+	traceID, spanID, flags := tracing.IDs(c.Request.Context())
+	for i := range msgs {
+		msgs[i].TraceID, msgs[i].SpanID, msgs[i].TraceFlags = traceID, spanID, flags
+	}
+	if len(msgs) > 0 {
+		if err := h.messages(c).CreateBatch(msgs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ids := make([]uuid.UUID, len(msgs))
+		for i, msg := range msgs {
+			ids[i] = msg.ID
+		}
+		h.enqueue(c, ids...)
+		h.broadcast("", msgs...)
+	}
+	c.JSON(http.StatusAccepted, SegmentSendResult{SegmentID: segmentID, Messages: len(msgs), Suppressed: expansion.Suppressed})
+}
+
+type MessageDetail struct {
+	model.Message
+	Timeline []model.StatusChange `json:"timeline"`
+}
+
+// GetMessage godoc
+// @Summary Get a message with its lifecycle timeline
+// @Description The timeline starts with the creation and lists every status change with its time and actor.
+// @Tags Messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Success 200 {object} MessageDetail
+// @Failure 404 {object} map[string]string
+// @Router /messages/{id} [get]
+func (h *Handler) GetMessage(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	msg, err := h.messages(c).GetByID(id)
+	if err != nil {
+		respondError(c, err, "message not found")
+		return
+	}
+
+	history, err := h.messages(c).History(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	timeline := make([]model.StatusChange, 0, len(history)+1)
+	timeline = append(timeline, model.StatusChange{
+		Event:     model.TransitionEvent("", model.StatusPending),
+		ToStatus:  model.StatusPending,
+		CreatedAt: msg.CreatedAt,
+	})
+	timeline = append(timeline, history...)
+
+	c.JSON(http.StatusOK, MessageDetail{Message: *msg, Timeline: timeline})
+}
+
+// CancelMessage godoc
+// @Summary Cancel a pending message
+// @Description Moves a PENDING message to CANCELLED. Messages already claimed by the worker or finished cannot be cancelled.
+// @Tags Messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Param X-Actor header string false "Who cancelled the message"
+// @Success 200 {object} model.Message
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /messages/{id}/cancel [post]
+func (h *Handler) CancelMessage(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	msg, err := h.messages(c).Cancel(id, actorFrom(c))
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "message is no longer pending"})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "message not found")
+		return
+	}
+	h.broadcast(model.StatusPending, *msg)
+	c.JSON(http.StatusOK, msg)
+}
+
+// RetryMessage godoc
+// @Summary Retry a failed message
+// @Description Moves a FAILED message back to PENDING so the worker sends it again. The caller is recorded from the X-Actor header.
+// @Tags Messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Param X-Actor header string false "Who triggered the retry"
+// @Success 200 {object} model.Message
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /messages/{id}/retry [post]
+func (h *Handler) RetryMessage(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	msg, err := h.messages(c).Retry(id, actorFrom(c))
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "only failed messages can be retried"})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "message not found")
+		return
+	}
+	h.enqueue(c, msg.ID)
+	h.broadcast(model.StatusFailed, *msg)
+	c.JSON(http.StatusOK, msg)
+}
+
+type RetryMessagesRequest struct {
+	Status       model.MessageStatus `json:"status"`
+	FailedAfter  *time.Time          `json:"failed_after"`
+	FailedBefore *time.Time          `json:"failed_before"`
+	CampaignID   *uuid.UUID          `json:"campaign_id"`
+	All          bool                `json:"all"` // required to retry every FAILED message when no filter is given
+}
+
+// RetryMessages godoc
+// @Summary Retry failed messages in bulk
+// @Description Resets every FAILED message matching the filter to PENDING, e.g. after a webhook outage. Only status FAILED can be retried. At least one of failed_after, failed_before and campaign_id is required; send `"all": true` instead to retry every failed message.
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Param filter body RetryMessagesRequest false "Filter"
+// @Param X-Actor header string false "Who triggered the retry"
+// @Success 200 {object} map[string]int64
+// @Failure 400 {object} map[string]string
+// @Router /messages/retry [post]
+func (h *Handler) RetryMessages(c *gin.Context) {
+	var req RetryMessagesRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Status != "" && req.Status != model.StatusFailed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only FAILED messages can be retried"})
+		return
+	}
+	if req.FailedAfter != nil && req.FailedBefore != nil && !req.FailedAfter.Before(*req.FailedBefore) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed_after must be before failed_before"})
+		return
+	}
+	if req.FailedAfter == nil && req.FailedBefore == nil && req.CampaignID == nil && !req.All {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "give failed_after, failed_before or campaign_id, or all=true to retry every failed message"})
+		return
+	}
+
+	actor := actorFrom(c)
+	retried, err := h.messages(c).RetryFailed(repository.RetryFilter{
+		FailedAfter:  req.FailedAfter,
+		FailedBefore: req.FailedBefore,
+		CampaignID:   req.CampaignID,
+	}, actor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := make([]uuid.UUID, len(retried))
+	for i, msg := range retried {
+		ids[i] = msg.ID
+	}
+	h.enqueue(c, ids...)
+	h.broadcast(model.StatusFailed, retried...)
+
+	slog.Info("failed messages reset to pending", "count", len(retried), "actor", actor)
+	c.JSON(http.StatusOK, gin.H{"retried": len(retried)})
+}
+
 // HealthCheck godoc
 // @Summary Health check endpoint
 // @Description Returns 200 OK if the server is running
@@ -101,30 +505,103 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+type CacheQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"`
+}
+
 // GetAllCachedMessages godoc
-// @Summary Get all cached messages
-// @Description Retrieves all messages currently stored in Redis cache.
+// @Summary List cached deliveries
+// @Description Walks the Redis cache of sent messages with SCAN, so large caches don't block Redis.
+// @Description Pass `next_cursor` back as `cursor` until it is empty. `limit` is a hint to Redis: a page may hold more or fewer entries, even none, before the scan completes.
 // @Tags Messages
 // @Produce json
-// @Success 200 {object} map[string]string
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Keys examined per page (default 50, max 1000)"
+// @Success 200 {object} service.CachePage
+// @Failure 400 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /messages/cache [get]
 func (h *Handler) GetAllCachedMessages(c *gin.Context) {
-	if h.Scheduler == nil || h.Scheduler.Sender == nil || h.Scheduler.Sender.Redis == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Redis not available"})
+	cache := h.sentCache(c)
+	if cache == nil {
 		return
 	}
 
-	keys, err := h.Scheduler.Sender.Redis.Keys(c.Request.Context(), "msg:*").Result()
+	var query CacheQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := cache.List(c.Request.Context(), query.Cursor, pageSize(query.Limit))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to scan cache"})
+		respondError(c, err, "")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetCachedByRemoteID godoc
+// @Summary Look up a cached delivery by the provider's message ID
+// @Tags Messages
+// @Produce json
+// @Param remote_id path string true "messageId returned by the webhook"
+// @Success 200 {object} service.CachedMessage
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /messages/cache/{remote_id} [get]
+func (h *Handler) GetCachedByRemoteID(c *gin.Context) {
+	cache := h.sentCache(c)
+	if cache == nil {
+		return
+	}
+
+	entry, err := cache.ByRemoteID(c.Request.Context(), c.Param("remote_id"))
+	h.respondCached(c, entry, err)
+}
+
+// GetCachedByMessageID godoc
+// @Summary Look up the cached delivery of a message
+// @Description Returns the record of the message's last delivery while it is cached (REDIS_TTL).
+// @Tags Messages
+// @Produce json
+// @Param id path string true "Message ID"
+// @Success 200 {object} service.CachedMessage
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /messages/{id}/cache [get]
+func (h *Handler) GetCachedByMessageID(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	cache := h.sentCache(c)
+	if cache == nil {
 		return
 	}
 
-	results := make(map[string]string)
-	for _, key := range keys {
-		val, _ := h.Scheduler.Sender.Redis.Get(c.Request.Context(), key).Result()
-		results[key] = val
+	entry, err := cache.ByMessageID(c.Request.Context(), id)
+	h.respondCached(c, entry, err)
+}
+
+// sentCache returns the delivery cache, writing a 503 when Redis isn't configured
+func (h *Handler) sentCache(c *gin.Context) *service.SentCache {
+	if h.Scheduler == nil || h.Scheduler.Sender == nil || h.Scheduler.Sender.Cache == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Redis not available"})
+		return nil
 	}
+	return h.Scheduler.Sender.Cache
+}
 
-	c.JSON(http.StatusOK, results)
+func (h *Handler) respondCached(c *gin.Context, entry *service.CachedMessage, err error) {
+	if errors.Is(err, service.ErrNotCached) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
 }
@@ -2,20 +2,31 @@ package handler_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"insider-assessment/internal/config"
 	"insider-assessment/internal/handler"
 	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
 	"insider-assessment/internal/service"
+	"insider-assessment/pkg/tracing"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 // MockRepository duplication for handler tests
@@ -23,26 +34,113 @@ type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) GetPending(limit int) ([]model.Message, error) {
+func (m *MockRepository) WithContext(ctx context.Context) repository.MessageRepository {
+	return m
+}
+
+func (m *MockRepository) ClaimPending(limit int) ([]model.Message, error) {
 	args := m.Called(limit)
 	return args.Get(0).([]model.Message), args.Error(1)
 }
 
+func (m *MockRepository) ClaimByIDs(ids []uuid.UUID, expiredBefore time.Time) ([]model.Message, error) {
+	args := m.Called(ids, expiredBefore)
+	if msgs, ok := args.Get(0).([]model.Message); ok {
+		return msgs, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) ReleaseExpired(claimedBefore time.Time, limit int) ([]model.Message, error) {
+	args := m.Called(claimedBefore, limit)
+	if msgs, ok := args.Get(0).([]model.Message); ok {
+		return msgs, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) Cancel(id uuid.UUID, actor string) (*model.Message, error) {
+	args := m.Called(id, actor)
+	if msg, ok := args.Get(0).(*model.Message); ok {
+		return msg, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockRepository) UpdateStatus(id uuid.UUID, status model.MessageStatus) error {
 	args := m.Called(id, status)
 	return args.Error(0)
 }
 
-func (m *MockRepository) GetAllSent() ([]model.Message, error) {
-	args := m.Called()
+func (m *MockRepository) Retry(id uuid.UUID, actor string) (*model.Message, error) {
+	args := m.Called(id, actor)
+	if msg, ok := args.Get(0).(*model.Message); ok {
+		return msg, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) Suppress(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockRepository) MarkDuplicate(id, originalID uuid.UUID) error {
+	return m.Called(id, originalID).Error(0)
+}
+
+func (m *MockRepository) RecentlySent(hashes []string, since time.Time) (map[string]uuid.UUID, error) {
+	args := m.Called(hashes, since)
+	return args.Get(0).(map[string]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) InFlight(hashes []string) (map[string]uuid.UUID, error) {
+	args := m.Called(hashes)
+	return args.Get(0).(map[string]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) Defer(id uuid.UUID, until time.Time) error {
+	args := m.Called(id, until)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RetryFailed(filter repository.RetryFilter, actor string) ([]model.Message, error) {
+	args := m.Called(filter, actor)
 	return args.Get(0).([]model.Message), args.Error(1)
 }
 
+func (m *MockRepository) List(filter repository.MessageFilter) ([]model.Message, string, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.Message), args.String(1), args.Error(2)
+}
+
+func (m *MockRepository) CompleteDelivery(id uuid.UUID, claimedAt *time.Time, status model.MessageStatus, latency time.Duration, remoteID string) error {
+	args := m.Called(id, claimedAt, status, latency, remoteID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetByID(id uuid.UUID) (*model.Message, error) {
+	args := m.Called(id)
+	if msg, ok := args.Get(0).(*model.Message); ok {
+		return msg, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) History(id uuid.UUID) ([]model.StatusChange, error) {
+	args := m.Called(id)
+	return args.Get(0).([]model.StatusChange), args.Error(1)
+}
+
 func (m *MockRepository) Create(msg *model.Message) error {
 	args := m.Called(msg)
 	return args.Error(0)
 }
 
+func (m *MockRepository) CreateBatch(msgs []model.Message) error {
+	args := m.Called(msgs)
+	return args.Error(0)
+}
+
 func setupRouter() (*gin.Engine, *handler.Handler, *MockRepository) {
 	gin.SetMode(gin.TestMode)
 	mockRepo := new(MockRepository)
@@ -51,15 +149,26 @@ func setupRouter() (*gin.Engine, *handler.Handler, *MockRepository) {
 	// though we might not assert on scheduler behavior deeply here.
 	cfg := &config.Config{WorkerInterval: time.Minute}
 	workerSvc := service.NewWorkerService(mockRepo, nil, cfg)
-	scheduler := service.NewScheduler(workerSvc, cfg)
+	scheduler, _ := service.NewScheduler(workerSvc, cfg)
 
 	h := handler.NewHandler(scheduler, mockRepo)
 
 	r := gin.Default()
 	r.POST("/start", h.StartScheduler)
 	r.POST("/stop", h.StopScheduler)
+	r.GET("/scheduler", h.GetScheduler)
+	r.PATCH("/scheduler", h.UpdateScheduler)
+	r.POST("/scheduler/run", h.RunScheduler)
 	r.GET("/sent-messages", h.GetSentMessages)
 	r.POST("/messages", h.AddMessage)
+	r.GET("/messages", h.ListMessages)
+	r.GET("/messages/cache", h.GetAllCachedMessages)
+	r.GET("/messages/cache/:remote_id", h.GetCachedByRemoteID)
+	r.GET("/messages/:id/cache", h.GetCachedByMessageID)
+	r.GET("/messages/:id", h.GetMessage)
+	r.POST("/messages/:id/cancel", h.CancelMessage)
+	r.POST("/messages/:id/retry", h.RetryMessage)
+	r.POST("/messages/retry", h.RetryMessages)
 	r.GET("/health", h.HealthCheck)
 
 	return r, h, mockRepo
@@ -82,19 +191,54 @@ func TestHandler_GetSentMessages(t *testing.T) {
 	messages := []model.Message{
 		{To: "+123", Content: "Test", Status: model.StatusSent},
 	}
-	mockRepo.On("GetAllSent").Return(messages, nil)
+	mockRepo.On("List", repository.MessageFilter{Status: model.StatusSent, Limit: 50}).Return(messages, "next-page", nil)
 
 	req, _ := http.NewRequest("GET", "/sent-messages", nil)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "next-page", w.Header().Get("X-Next-Cursor"))
 	var response []model.Message
 	json.Unmarshal(w.Body.Bytes(), &response)
 	assert.Len(t, response, 1)
 	assert.Equal(t, "+123", response[0].To)
 }
 
+func TestHandler_GetSentMessagesAppliesFilters(t *testing.T) {
+	r, _, mockRepo := setupRouter()
+
+	campaignID := uuid.New()
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sent := []model.Message{{To: "+905551112233", Content: "Sale", Status: model.StatusSent, CampaignID: &campaignID}}
+	mockRepo.On("List", repository.MessageFilter{
+		Status:     model.StatusSent,
+		To:         "+905551112233",
+		CampaignID: &campaignID,
+		SentAfter:  &after,
+		Descending: true,
+		Limit:      10,
+	}).Return(sent, "", nil)
+
+	url := "/sent-messages?to=%2B905551112233&campaign_id=" + campaignID.String() + "&sent_after=2025-01-01T00:00:00Z&order=desc&limit=10"
+	req, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response []model.Message
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response, 1)
+	assert.Equal(t, campaignID, *response[0].CampaignID)
+	assert.Empty(t, w.Header().Get("X-Next-Cursor"))
+
+	req, _ = http.NewRequest("GET", "/sent-messages?status=failed", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNumberOfCalls(t, "List", 1)
+}
+
 func TestHandler_AddMessage(t *testing.T) {
 	r, _, mockRepo := setupRouter()
 
@@ -111,8 +255,57 @@ func TestHandler_AddMessage(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestHandler_AddMessageNormalizesCategory(t *testing.T) {
+	r, _, mockRepo := setupRouter()
+	mockRepo.On("Create", mock.MatchedBy(func(msg *model.Message) bool {
+		return msg.Category == model.CategoryTransactional
+	})).Return(nil).Once()
+	mockRepo.On("Create", mock.MatchedBy(func(msg *model.Message) bool {
+		return msg.Category == model.CategoryMarketing
+	})).Return(nil).Once()
+
+	for _, body := range []string{
+		`{"to": "+905551112233", "content": "Your code is 1234", "category": "Transactional"}`,
+		`{"to": "+905551112233", "content": "Sale!"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/messages", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_AddMessagePersistsTraceID(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	gin.SetMode(gin.TestMode)
+	mockRepo := new(MockRepository)
+	h := handler.NewHandler(nil, mockRepo)
+
+	r := gin.New()
+	r.Use(tracing.Middleware())
+	r.POST("/messages", h.AddMessage)
+
+	mockRepo.On("Create", mock.MatchedBy(func(msg *model.Message) bool {
+		return msg.TraceID == "4bf92f3577b34da6a3ce929d0e0e4736" && msg.SpanID == "00f067aa0ba902b7" && msg.TraceFlags == 1
+	})).Return(nil)
+
+	body, _ := json.Marshal(model.Message{To: "+123", Content: "Traced"})
+	req, _ := http.NewRequest("POST", "/messages", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get(tracing.TraceIDHeader))
+	mockRepo.AssertExpectations(t)
+}
+
 func TestHandler_StartStopScheduler(t *testing.T) {
-	r, h, _ := setupRouter()
+	r, h, mockRepo := setupRouter()
+	mockRepo.On("ClaimPending", mock.Anything).Return([]model.Message{}, nil).Maybe()
 
 	// Test Start
 	req, _ := http.NewRequest("POST", "/start", nil)
@@ -120,12 +313,998 @@ func TestHandler_StartStopScheduler(t *testing.T) {
 	r.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	// Cleanup: Stop the scheduler to avoid leaking goroutines
-	h.Scheduler.Stop()
+	// Starting again is a no-op and reported as a conflict
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Cleanup: end the ticker loop to avoid leaking goroutines
+	defer h.Scheduler.Shutdown()
 
 	// Test Stop
 	reqStop, _ := http.NewRequest("POST", "/stop", nil)
 	wStop := httptest.NewRecorder()
 	r.ServeHTTP(wStop, reqStop)
 	assert.Equal(t, http.StatusOK, wStop.Code)
+	assert.False(t, h.Scheduler.Running())
+
+	// Stopping again is a conflict as well
+	wStop = httptest.NewRecorder()
+	r.ServeHTTP(wStop, reqStop)
+	assert.Equal(t, http.StatusConflict, wStop.Code)
+}
+
+func TestHandler_UpdateScheduler(t *testing.T) {
+	r, _, _ := setupRouter()
+
+	req, _ := http.NewRequest("PATCH", "/scheduler", bytes.NewBufferString(`{"interval":"30s","batch_size":10}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/scheduler", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var status service.SchedulerStatus
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.False(t, status.Running)
+	assert.Equal(t, "30s", status.Interval)
+	assert.Equal(t, 10, status.BatchSize)
+	assert.Nil(t, status.NextTick)
+
+	for _, body := range []string{`{"interval":"soon"}`, `{"interval":"10ms"}`, `{"batch_size":0}`, `{"schedule":"* * *"}`, `{"window":"Mon-Fri"}`} {
+		req, _ = http.NewRequest("PATCH", "/scheduler", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestHandler_CancelMessage(t *testing.T) {
+	r, h, mockRepo := setupRouter()
+	queue := new(MockQueue)
+	h.Queue = queue
+
+	pendingID, sentID, missingID := uuid.New(), uuid.New(), uuid.New()
+	mockRepo.On("Cancel", pendingID, "api").Return(&model.Message{ID: pendingID, Status: model.StatusCancelled}, nil)
+	mockRepo.On("Cancel", sentID, "api").Return(nil, repository.ErrConflict)
+	mockRepo.On("Cancel", missingID, "api").Return(nil, repository.ErrNotFound)
+
+	cases := []struct {
+		id   string
+		code int
+	}{
+		{pendingID.String(), http.StatusOK},
+		{sentID.String(), http.StatusConflict},
+		{missingID.String(), http.StatusNotFound},
+		{"not-a-uuid", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequest("POST", "/messages/"+tc.id+"/cancel", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.code, w.Code, tc.id)
+	}
+
+	mockRepo.AssertExpectations(t)
+	// a cancelled message is never claimed, so it isn't announced to the queue
+	queue.AssertNotCalled(t, "Enqueue", mock.Anything)
+}
+
+func TestHandler_RetryMessage(t *testing.T) {
+	r, h, mockRepo := setupRouter()
+	queue := new(MockQueue)
+	h.Queue = queue
+
+	failedID, pendingID := uuid.New(), uuid.New()
+	mockRepo.On("Retry", failedID, "ops-oncall").Return(&model.Message{ID: failedID, Status: model.StatusPending}, nil)
+	mockRepo.On("Retry", pendingID, "api").Return(nil, repository.ErrConflict)
+	queue.On("Enqueue", []uuid.UUID{failedID}).Return(nil).Once()
+
+	req, _ := http.NewRequest("POST", "/messages/"+failedID.String()+"/retry", nil)
+	req.Header.Set("X-Actor", "ops-oncall")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("POST", "/messages/"+pendingID.String()+"/retry", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	mockRepo.AssertExpectations(t)
+	queue.AssertExpectations(t)
+}
+
+// failedMessages returns n messages as RetryFailed returns them, and their IDs
+func failedMessages(n int) ([]model.Message, []uuid.UUID) {
+	msgs := make([]model.Message, n)
+	ids := make([]uuid.UUID, n)
+	for i := range msgs {
+		msgs[i] = model.Message{ID: uuid.New(), Status: model.StatusPending, To: "+905551112233"}
+		ids[i] = msgs[i].ID
+	}
+	return msgs, ids
+}
+
+func TestHandler_RetryMessages(t *testing.T) {
+	r, h, mockRepo := setupRouter()
+	queue := new(MockQueue)
+	h.Queue = queue
+
+	after := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	before := after.Add(time.Hour)
+	retried, ids := failedMessages(7)
+	mockRepo.On("RetryFailed", repository.RetryFilter{FailedAfter: &after, FailedBefore: &before}, "ops-oncall").Return(retried, nil)
+	queue.On("Enqueue", ids).Return(nil).Once()
+
+	body := `{"status": "FAILED", "failed_after": "2025-01-01T10:00:00Z", "failed_before": "2025-01-01T11:00:00Z"}`
+	req, _ := http.NewRequest("POST", "/messages/retry", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Actor", "ops-oncall")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"retried": 7}`, w.Body.String())
+
+	req, _ = http.NewRequest("POST", "/messages/retry", bytes.NewBufferString(`{"status": "SENT"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// an unfiltered retry must be explicit
+	req, _ = http.NewRequest("POST", "/messages/retry", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNumberOfCalls(t, "RetryFailed", 1)
+
+	retried, ids = failedMessages(40)
+	mockRepo.On("RetryFailed", repository.RetryFilter{}, "api").Return(retried, nil)
+	queue.On("Enqueue", ids).Return(nil).Once()
+	req, _ = http.NewRequest("POST", "/messages/retry", bytes.NewBufferString(`{"all": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"retried": 40}`, w.Body.String())
+
+	mockRepo.AssertExpectations(t)
+	queue.AssertExpectations(t)
+}
+
+func TestHandler_ListMessages(t *testing.T) {
+	r, _, mockRepo := setupRouter()
+
+	campaignID := uuid.New()
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("List", repository.MessageFilter{
+		Status:       model.StatusFailed,
+		To:           "+905551112233",
+		CampaignID:   &campaignID,
+		CreatedAfter: &after,
+		Descending:   true,
+		Cursor:       "abc",
+		Limit:        10,
+	}).Return([]model.Message{{To: "+905551112233", Status: model.StatusFailed}}, "", nil)
+
+	url := "/messages?status=failed&to=%2B905551112233&campaign_id=" + campaignID.String() +
+		"&created_after=2025-01-01T00:00:00Z&order=desc&cursor=abc&limit=10"
+	req, _ := http.NewRequest("GET", url, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page handler.MessagePage
+	json.Unmarshal(w.Body.Bytes(), &page)
+	assert.Len(t, page.Messages, 1)
+	assert.Empty(t, page.NextCursor)
+
+	req, _ = http.NewRequest("GET", "/messages?limit=5000", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// a misspelled status is an error, not an empty page
+	for _, url := range []string{"/messages?status=SENTT", "/sent-messages?status=SENTT"} {
+		req, _ = http.NewRequest("GET", url, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+		assert.Contains(t, w.Body.String(), `unknown status`)
+	}
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "List", 1)
+}
+
+func TestHandler_ListMessagesNormalizesRecipient(t *testing.T) {
+	r, _, mockRepo := setupRouter()
+
+	mockRepo.On("List", repository.MessageFilter{To: "+905551112233", Limit: 50}).Return([]model.Message{}, "", nil)
+	mockRepo.On("List", repository.MessageFilter{Status: model.StatusSent, To: "+905551112233", Limit: 50}).Return([]model.Message{}, "", nil)
+
+	// without '+', with a '+' the query string turned into a space, and formatted
+	for _, url := range []string{
+		"/messages?to=905551112233",
+		"/messages?to=+905551112233",
+		"/messages?to=%2B90%20555%20111%2022%2033",
+		"/sent-messages?to=00905551112233",
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, url)
+	}
+	mockRepo.AssertNumberOfCalls(t, "List", 4)
+
+	for _, url := range []string{"/messages?to=not-a-number", "/sent-messages?to=123"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+		assert.Contains(t, w.Body.String(), "invalid recipient")
+	}
+	mockRepo.AssertNumberOfCalls(t, "List", 4)
+}
+
+func TestHandler_GetMessage(t *testing.T) {
+	r, _, mockRepo := setupRouter()
+
+	id := uuid.New()
+	created := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	mockRepo.On("GetByID", id).Return(&model.Message{ID: id, To: "+123", Status: model.StatusSent, CreatedAt: created}, nil)
+	mockRepo.On("History", id).Return([]model.StatusChange{
+		{Event: "claimed", FromStatus: model.StatusPending, ToStatus: model.StatusProcessing, Actor: "worker", CreatedAt: created.Add(time.Minute)},
+		{Event: "sent", FromStatus: model.StatusProcessing, ToStatus: model.StatusSent, Actor: "worker", CreatedAt: created.Add(time.Minute)},
+	}, nil)
+	mockRepo.On("GetByID", mock.Anything).Return(nil, repository.ErrNotFound)
+
+	req, _ := http.NewRequest("GET", "/messages/"+id.String(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var detail handler.MessageDetail
+	json.Unmarshal(w.Body.Bytes(), &detail)
+	assert.Equal(t, id, detail.ID)
+	if assert.Len(t, detail.Timeline, 3) {
+		assert.Equal(t, "created", detail.Timeline[0].Event)
+		assert.True(t, created.Equal(detail.Timeline[0].CreatedAt))
+		assert.Equal(t, "sent", detail.Timeline[2].Event)
+	}
+
+	req, _ = http.NewRequest("GET", "/messages/"+uuid.NewString(), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+type MockStatsRepository struct {
+	mock.Mock
+}
+
+func (m *MockStatsRepository) Collect() (*repository.Stats, error) {
+	args := m.Called()
+	return args.Get(0).(*repository.Stats), args.Error(1)
+}
+
+func (m *MockStatsRepository) CountPending() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestHandler_GetStats(t *testing.T) {
+	r, h, _ := setupRouter()
+	r.GET("/stats", h.GetStats)
+
+	statsRepo := new(MockStatsRepository)
+	statsRepo.On("Collect").Return(&repository.Stats{
+		Counts:         map[model.MessageStatus]int64{model.StatusPending: 4, model.StatusSent: 10},
+		SentLastHour:   10,
+		FailedLastHour: 0,
+		LatencyP95Ms:   120,
+	}, nil)
+	h.Stats = service.NewStatsService(statsRepo, nil, time.Second)
+
+	req, _ := http.NewRequest("GET", "/stats", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var stats repository.Stats
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	assert.Equal(t, int64(4), stats.Counts[model.StatusPending])
+	assert.Equal(t, 120.0, stats.LatencyP95Ms)
+	statsRepo.AssertExpectations(t)
+}
+
+func TestHandler_AddMessageRejectsUnknownTimezone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := new(MockRepository)
+	h := handler.NewHandler(nil, mockRepo)
+
+	r := gin.New()
+	r.POST("/messages", h.AddMessage)
+
+	for _, body := range []string{
+		`{"to":"+905551112233","content":"Hi","timezone":"Mars/Olympus"}`,
+		`{"to":"+905551112233","content":"Hi","category":"promo"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/messages", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestHandler_RunScheduler(t *testing.T) {
+	r, h, mockRepo := setupRouter()
+	mockRepo.On("ClaimPending", 0).Return([]model.Message{}, nil).Maybe()
+	mockRepo.On("ClaimPending", 5).Return([]model.Message{}, nil).Once()
+
+	run := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/scheduler/run", bytes.NewBufferString(`{"batch_size":5}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// a stopped scheduler doesn't send on demand either
+	w := run()
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), service.ErrNotRunning.Error())
+
+	assert.NoError(t, h.Scheduler.Start("test"))
+	defer h.Scheduler.Shutdown()
+	// the tick run on start may still hold the cycle
+	assert.Eventually(t, func() bool {
+		w = run()
+		return w.Code != http.StatusConflict
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result service.BatchResult
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 0, result.Claimed)
+
+	req, _ := http.NewRequest("POST", "/scheduler/run", bytes.NewBufferString(`{"batch_size":5000}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_CacheEndpointsValidateInput(t *testing.T) {
+	r, h, _ := setupRouter()
+
+	for _, path := range []string{"/messages/cache", "/messages/cache/external-123", "/messages/" + uuid.NewString() + "/cache"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, path)
+	}
+
+	// nothing listens here; both requests are rejected before Redis is called
+	h.Scheduler.Sender.Cache = service.NewSentCache(redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"}), time.Hour, "secret")
+	for _, path := range []string{"/messages/cache?limit=5000", "/messages/cache?cursor=abc", "/messages/not-a-uuid/cache"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+}
+
+type MockQueue struct {
+	mock.Mock
+}
+
+func (m *MockQueue) Enqueue(ctx context.Context, ids ...uuid.UUID) error {
+	return m.Called(ids).Error(0)
+}
+
+func (m *MockQueue) Claim(ctx context.Context, limit int) ([]model.Message, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]model.Message), args.Error(1)
+}
+
+func (m *MockQueue) Ack(ctx context.Context, ids ...uuid.UUID) error {
+	return m.Called(ids).Error(0)
+}
+
+func TestHandler_AddMessageEnqueues(t *testing.T) {
+	r, h, mockRepo := setupRouter()
+	queue := new(MockQueue)
+	h.Queue = queue
+
+	var created uuid.UUID
+	mockRepo.On("Create", mock.AnythingOfType("*model.Message")).Run(func(args mock.Arguments) {
+		msg := args.Get(0).(*model.Message)
+		msg.ID = uuid.New()
+		created = msg.ID
+	}).Return(nil)
+	// a queue outage doesn't fail the request; the worker finds the row by polling
+	queue.On("Enqueue", mock.MatchedBy(func(ids []uuid.UUID) bool {
+		return len(ids) == 1 && ids[0] == created
+	})).Return(errors.New("redis down"))
+
+	req, _ := http.NewRequest("POST", "/messages", bytes.NewBufferString(`{"to":"+905551112233","content":"Hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	queue.AssertExpectations(t)
+}
+
+type MockSubscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSubscriptionRepository) Create(sub *model.EventSubscription) error {
+	return m.Called(sub).Error(0)
+}
+
+func (m *MockSubscriptionRepository) List() ([]model.EventSubscription, error) {
+	args := m.Called()
+	return args.Get(0).([]model.EventSubscription), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) Delete(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func TestHandler_EventSubscriptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	subs := new(MockSubscriptionRepository)
+	h := handler.NewHandler(nil, nil)
+	h.Events = service.NewEventPublisher(nil, "events", subs)
+
+	r := gin.New()
+	r.POST("/event-subscriptions", h.CreateSubscription)
+	r.DELETE("/event-subscriptions/:id", h.DeleteSubscription)
+
+	subs.On("Create", mock.MatchedBy(func(sub *model.EventSubscription) bool {
+		return sub.URL == "https://example.com/hook" && sub.Secret == "s3cret"
+	})).Return(nil)
+	missing := uuid.New()
+	subs.On("Delete", missing).Return(repository.ErrNotFound)
+
+	cases := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"POST", "/event-subscriptions", `{"url":"https://example.com/hook","events":["message.sent"],"secret":"s3cret"}`, http.StatusCreated},
+		{"POST", "/event-subscriptions", `{"url":"not a url"}`, http.StatusBadRequest},
+		{"POST", "/event-subscriptions", `{"url":"https://example.com/hook","events":["message.read"]}`, http.StatusBadRequest},
+		{"DELETE", "/event-subscriptions/" + missing.String(), "", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.want, w.Code, tc.body)
+		assert.NotContains(t, w.Body.String(), "s3cret") // the secret is never echoed
+	}
+	subs.AssertExpectations(t)
+}
+
+func TestHandler_StreamMessages(t *testing.T) {
+	r, h, _ := setupRouter()
+	r.GET("/messages/stream", h.StreamMessages)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/messages/stream", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	updates := service.NewBroadcaster(10)
+	h.Scheduler.Sender.Updates = updates
+	missed := service.StatusUpdate{MessageID: uuid.New(), To: "+905551112233", Status: model.StatusSent}
+	updates.Publish(
+		service.StatusUpdate{MessageID: uuid.New(), To: "+905551112233", Status: model.StatusProcessing},
+		missed,
+		service.StatusUpdate{MessageID: uuid.New(), To: "+905559998877", Status: model.StatusSent},
+	)
+	probe, kept := updates.Subscribe(service.UpdateFilter{}, 1)
+	probe.Close()
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/messages/stream?status=sent&to=%2B905551112233", nil)
+	req.Header.Set("Last-Event-ID", strconv.FormatUint(kept[0].ID, 10))
+	ctx, cancel := context.WithCancel(req.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ServeHTTP(w, req.WithContext(ctx))
+	}()
+	require.Eventually(t, func() bool { return updates.Listeners() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Equal(t, 1, strings.Count(body, "event: status"))
+	assert.Contains(t, body, fmt.Sprintf("id: %d\n", kept[1].ID))
+	assert.Contains(t, body, missed.MessageID.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/messages/stream", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/messages/stream?status=delivered", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown status")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/messages/stream?to=abc", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid recipient")
+}
+
+func TestHandler_PublishesApiTransitions(t *testing.T) {
+	r, h, mockRepo := setupRouter()
+	updates := service.NewBroadcaster(10)
+	h.Scheduler.Sender.Updates = updates
+	listener, _ := updates.Subscribe(service.UpdateFilter{}, 0)
+	defer listener.Close()
+
+	created := uuid.New()
+	mockRepo.On("Create", mock.AnythingOfType("*model.Message")).Run(func(args mock.Arguments) {
+		args.Get(0).(*model.Message).ID = created
+	}).Return(nil)
+	cancelled := model.Message{ID: uuid.New(), To: "+905551112233", Status: model.StatusCancelled}
+	mockRepo.On("Cancel", cancelled.ID, "api").Return(&cancelled, nil)
+	retried := model.Message{ID: uuid.New(), To: "+905551112233", Status: model.StatusPending}
+	mockRepo.On("Retry", retried.ID, "api").Return(&retried, nil)
+	bulk, _ := failedMessages(2)
+	mockRepo.On("RetryFailed", repository.RetryFilter{}, "api").Return(bulk, nil)
+
+	for _, call := range []struct{ path, body string }{
+		{"/messages", `{"to":"+905551112233","content":"Hi"}`},
+		{"/messages/" + cancelled.ID.String() + "/cancel", ""},
+		{"/messages/" + retried.ID.String() + "/retry", ""},
+		{"/messages/retry", `{"all": true}`},
+	} {
+		req, _ := http.NewRequest("POST", call.path, bytes.NewBufferString(call.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Less(t, w.Code, 300, call.path)
+	}
+
+	want := []service.StatusUpdate{
+		{MessageID: created, To: "+905551112233", Status: model.StatusPending},
+		{MessageID: cancelled.ID, To: cancelled.To, From: model.StatusPending, Status: model.StatusCancelled},
+		{MessageID: retried.ID, To: retried.To, From: model.StatusFailed, Status: model.StatusPending},
+		{MessageID: bulk[0].ID, To: bulk[0].To, From: model.StatusFailed, Status: model.StatusPending},
+		{MessageID: bulk[1].ID, To: bulk[1].To, From: model.StatusFailed, Status: model.StatusPending},
+	}
+	for _, expected := range want {
+		got := <-listener.C
+		got.ID, got.At = 0, time.Time{}
+		assert.Equal(t, expected, got)
+	}
+}
+
+// MockSuppressionRepository is a mock implementation of repository.SuppressionRepository
+type MockSuppressionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSuppressionRepository) Add(s *model.Suppression) error {
+	return m.Called(s).Error(0)
+}
+
+func (m *MockSuppressionRepository) Remove(phone string) error {
+	return m.Called(phone).Error(0)
+}
+
+func (m *MockSuppressionRepository) List(cursor string, limit int) ([]model.Suppression, string, error) {
+	args := m.Called(cursor, limit)
+	return args.Get(0).([]model.Suppression), args.String(1), args.Error(2)
+}
+
+func (m *MockSuppressionRepository) Suppressed(phones []string) (map[string]bool, error) {
+	args := m.Called(phones)
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
+func TestHandler_Suppressions(t *testing.T) {
+	r, h, mockRepo := setupRouter()
+	r.POST("/suppressions", h.CreateSuppression)
+	r.GET("/suppressions", h.ListSuppressions)
+	r.DELETE("/suppressions/:phone", h.DeleteSuppression)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/suppressions", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	suppressions := new(MockSuppressionRepository)
+	h.Suppressions = service.NewSuppressionService(suppressions, "STOP", "START")
+
+	// opted-out recipients are rejected before anything is stored
+	suppressions.On("Suppressed", []string{"+905551112233"}).Return(map[string]bool{"+905551112233": true}, nil)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/messages", bytes.NewBufferString(`{"to": "+905551112233", "content": "Sale!"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+	suppressions.On("Add", mock.MatchedBy(func(s *model.Suppression) bool {
+		return s.Phone == "+905559998877" && s.Source == model.SuppressionSourceAPI
+	})).Return(nil).Once()
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/suppressions", bytes.NewBufferString(`{"phone": "+90 555 999 88 77", "reason": "complaint"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	suppressions.On("Add", mock.Anything).Return(repository.ErrConflict).Once()
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/suppressions", bytes.NewBufferString(`{"phone": "+905559998877"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	suppressions.On("List", "", 50).Return([]model.Suppression{{Phone: "+905559998877", Source: "api"}}, "", nil)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/suppressions", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"phone":"+905559998877"`)
+
+	suppressions.On("Remove", "+905550000000").Return(repository.ErrNotFound)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/suppressions/+905550000000", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	suppressions.AssertExpectations(t)
+}
+
+// MockInboundRepository is a mock implementation of repository.InboundRepository
+type MockInboundRepository struct {
+	mock.Mock
+}
+
+func (m *MockInboundRepository) Create(msg *model.InboundMessage) (bool, error) {
+	args := m.Called(msg)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInboundRepository) ByRemoteID(remoteID string) (*model.InboundMessage, error) {
+	args := m.Called(remoteID)
+	msg, _ := args.Get(0).(*model.InboundMessage)
+	return msg, args.Error(1)
+}
+
+func (m *MockInboundRepository) LastSent(recipients []string) (*uuid.UUID, error) {
+	args := m.Called(recipients)
+	id, _ := args.Get(0).(*uuid.UUID)
+	return id, args.Error(1)
+}
+
+func (m *MockInboundRepository) Replies(from string, before *repository.Position, limit int) ([]model.InboundMessage, error) {
+	args := m.Called(from, before, limit)
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *MockInboundRepository) Sent(recipients []string, before *repository.Position, limit int) ([]model.Message, error) {
+	args := m.Called(recipients, before, limit)
+	return args.Get(0).([]model.Message), args.Error(1)
+}
+
+func TestHandler_Inbound(t *testing.T) {
+	r, h, _ := setupRouter()
+	r.POST("/inbound", h.ReceiveInbound)
+	r.GET("/conversations/:phone", h.GetConversation)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/inbound", bytes.NewBufferString(`{"from": "+905551234567", "content": "Stop"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	inbound := new(MockInboundRepository)
+	suppressions := new(MockSuppressionRepository)
+	h.Inbound = service.NewInboundService(inbound, service.NewSuppressionService(suppressions, "STOP", "START"))
+
+	lastSent := uuid.New()
+	inbound.On("LastSent", []string{"+905551234567"}).Return(&lastSent, nil)
+	inbound.On("Create", mock.MatchedBy(func(m *model.InboundMessage) bool {
+		return m.From == "+905551234567" && *m.InReplyTo == lastSent
+	})).Return(true, nil)
+	suppressions.On("Add", mock.MatchedBy(func(s *model.Suppression) bool {
+		return s.Phone == "+905551234567" && s.Source == model.SuppressionSourceInbound
+	})).Return(nil)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/inbound", bytes.NewBufferString(`{"from": "+905551234567", "content": "Stop"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp handler.InboundMessageResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "opted_out", resp.Action)
+	assert.Equal(t, lastSent, *resp.Message.InReplyTo)
+
+	sentAt := time.Now().Add(-time.Hour)
+	inbound.On("Sent", []string{"+905551234567"}, (*repository.Position)(nil), 51).Return([]model.Message{{ID: lastSent, Content: "Sale!", SentAt: &sentAt}}, nil)
+	inbound.On("Replies", "+905551234567", (*repository.Position)(nil), 51).Return([]model.InboundMessage{{ID: uuid.New(), Content: "Stop", ReceivedAt: time.Now()}}, nil)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/conversations/+905551234567", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var conversation service.Conversation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &conversation))
+	require.Len(t, conversation.Entries, 2)
+	assert.Equal(t, service.DirectionInbound, conversation.Entries[0].Direction)
+	assert.Equal(t, service.DirectionOutbound, conversation.Entries[1].Direction)
+	assert.Empty(t, conversation.NextCursor)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/conversations/+905551234567?cursor=yesterday", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// MockContactRepository is a mock implementation of repository.ContactRepository
+type MockContactRepository struct {
+	mock.Mock
+}
+
+func (m *MockContactRepository) Create(contact *model.Contact) error {
+	return m.Called(contact).Error(0)
+}
+
+func (m *MockContactRepository) GetByID(id uuid.UUID) (*model.Contact, error) {
+	args := m.Called(id)
+	contact, _ := args.Get(0).(*model.Contact)
+	return contact, args.Error(1)
+}
+
+func (m *MockContactRepository) Update(contact *model.Contact) error {
+	return m.Called(contact).Error(0)
+}
+
+func (m *MockContactRepository) Delete(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockContactRepository) List(cursor string, limit int) ([]model.Contact, string, error) {
+	args := m.Called(cursor, limit)
+	return args.Get(0).([]model.Contact), args.String(1), args.Error(2)
+}
+
+func (m *MockContactRepository) Match(conditions []model.SegmentCondition, afterPhone string, limit int) ([]model.Contact, error) {
+	args := m.Called(conditions, afterPhone, limit)
+	return args.Get(0).([]model.Contact), args.Error(1)
+}
+
+func (m *MockContactRepository) Count(conditions []model.SegmentCondition) (int64, error) {
+	args := m.Called(conditions)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockSegmentRepository is a mock implementation of repository.SegmentRepository
+type MockSegmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockSegmentRepository) Create(segment *model.Segment) error {
+	return m.Called(segment).Error(0)
+}
+
+func (m *MockSegmentRepository) GetByID(id uuid.UUID) (*model.Segment, error) {
+	args := m.Called(id)
+	segment, _ := args.Get(0).(*model.Segment)
+	return segment, args.Error(1)
+}
+
+func (m *MockSegmentRepository) Update(segment *model.Segment) error {
+	return m.Called(segment).Error(0)
+}
+
+func (m *MockSegmentRepository) Delete(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockSegmentRepository) List() ([]model.Segment, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Segment), args.Error(1)
+}
+
+func TestHandler_Contacts(t *testing.T) {
+	r, h, _ := setupRouter()
+	r.POST("/contacts", h.CreateContact)
+	r.PUT("/contacts/:id", h.UpdateContact)
+	r.POST("/segments", h.CreateSegment)
+	r.GET("/segments/:id", h.GetSegment)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/contacts", bytes.NewBufferString(`{"phone": "+905551234567"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	contacts := new(MockContactRepository)
+	segments := new(MockSegmentRepository)
+	h.Contacts = service.NewContactService(contacts, segments)
+
+	contacts.On("Create", mock.MatchedBy(func(c *model.Contact) bool {
+		return c.Phone == "+905551234567" && c.Attributes["tier"] == "gold"
+	})).Return(nil).Once()
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/contacts", bytes.NewBufferString(`{"phone": "+90 555 123 45 67", "name": "Ayşe", "attributes": {"tier": "gold"}}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	contacts.On("Create", mock.Anything).Return(repository.ErrConflict).Once()
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/contacts", bytes.NewBufferString(`{"phone": "+905551234567"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	missing := uuid.New()
+	contacts.On("Update", mock.MatchedBy(func(c *model.Contact) bool { return c.ID == missing })).Return(repository.ErrNotFound)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/contacts/"+missing.String(), bytes.NewBufferString(`{"phone": "+905551234567"}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/segments", bytes.NewBufferString(`{"name": "gold", "conditions": [{"field": "attributes.tier", "op": "like", "value": "g%"}]}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	segment := model.Segment{ID: uuid.New(), Name: "gold", Conditions: []model.SegmentCondition{{Field: "attributes.tier", Op: model.OpEquals, Value: "gold"}}}
+	segments.On("GetByID", segment.ID).Return(&segment, nil)
+	contacts.On("Count", segment.Conditions).Return(int64(42), nil)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/segments/"+segment.ID.String(), nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var view service.SegmentView
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+	assert.Equal(t, int64(42), view.Contacts)
+	assert.Equal(t, "gold", view.Name)
+}
+
+func TestHandler_AddMessageToSegment(t *testing.T) {
+	r, h, mockRepo := setupRouter()
+	contacts := new(MockContactRepository)
+	segments := new(MockSegmentRepository)
+	h.Contacts = service.NewContactService(contacts, segments)
+
+	segment := model.Segment{ID: uuid.New(), Name: "everyone", Conditions: []model.SegmentCondition{}}
+	segments.On("GetByID", segment.ID).Return(&segment, nil)
+	contacts.On("Match", segment.Conditions, "", 500).Return([]model.Contact{
+		{Phone: "+905551234567", Name: "Ayşe", Timezone: "Europe/Istanbul"},
+		{Phone: "+905551234568", Name: "Can"},
+	}, nil)
+	mockRepo.On("CreateBatch", mock.MatchedBy(func(msgs []model.Message) bool {
+		return len(msgs) == 2 && msgs[0].Content == "Hi Ayşe" && msgs[0].Timezone == "Europe/Istanbul" && msgs[1].Content == "Hi Can"
+	})).Return(nil)
+
+	w := httptest.NewRecorder()
+	body := fmt.Sprintf(`{"segment_id": %q, "content": "Hi {{name}}"}`, segment.ID)
+	req, _ := http.NewRequest("POST", "/messages", bytes.NewBufferString(body))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var result handler.SegmentSendResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 2, result.Messages)
+	assert.Equal(t, segment.ID, result.SegmentID)
+
+	// to and segment_id are mutually exclusive
+	w = httptest.NewRecorder()
+	body = fmt.Sprintf(`{"to": "+905551234567", "segment_id": %q, "content": "Hi"}`, segment.ID)
+	req, _ = http.NewRequest("POST", "/messages", bytes.NewBufferString(body))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	missing := uuid.New()
+	segments.On("GetByID", missing).Return(nil, repository.ErrNotFound)
+	w = httptest.NewRecorder()
+	body = fmt.Sprintf(`{"segment_id": %q, "content": "Hi"}`, missing)
+	req, _ = http.NewRequest("POST", "/messages", bytes.NewBufferString(body))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// a segment matching nobody is accepted with nothing to send
+	empty := model.Segment{ID: uuid.New(), Conditions: []model.SegmentCondition{{Field: "locale", Op: model.OpEquals, Value: "xx-XX"}}}
+	segments.On("GetByID", empty.ID).Return(&empty, nil)
+	contacts.On("Match", empty.Conditions, "", 500).Return([]model.Contact{}, nil)
+	w = httptest.NewRecorder()
+	body = fmt.Sprintf(`{"segment_id": %q, "content": "Hi"}`, empty.ID)
+	req, _ = http.NewRequest("POST", "/messages", bytes.NewBufferString(body))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 0, result.Messages)
+
+	// a segment larger than the limit is refused
+	h.Contacts.MaxSegmentSize = 1
+	w = httptest.NewRecorder()
+	body = fmt.Sprintf(`{"segment_id": %q, "content": "Hi {{name}}"}`, segment.ID)
+	req, _ = http.NewRequest("POST", "/messages", bytes.NewBufferString(body))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "more than 1 contacts")
+	mockRepo.AssertNumberOfCalls(t, "CreateBatch", 1)
+}
+
+// MockCampaignRepository is a mock implementation of repository.CampaignRepository
+type MockCampaignRepository struct {
+	mock.Mock
+}
+
+func (m *MockCampaignRepository) Create(campaign *model.Campaign) error {
+	return m.Called(campaign).Error(0)
+}
+
+func (m *MockCampaignRepository) GetByID(id uuid.UUID) (*model.Campaign, error) {
+	args := m.Called(id)
+	campaign, _ := args.Get(0).(*model.Campaign)
+	return campaign, args.Error(1)
+}
+
+func (m *MockCampaignRepository) Launch(id uuid.UUID, messages []model.Message) error {
+	return m.Called(id, messages).Error(0)
+}
+
+func (m *MockCampaignRepository) Transition(id uuid.UUID, from []model.CampaignStatus, to model.CampaignStatus) error {
+	return m.Called(id, from, to).Error(0)
+}
+
+func (m *MockCampaignRepository) Cancel(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockCampaignRepository) CountMessages(id uuid.UUID) (map[model.MessageStatus]int64, error) {
+	args := m.Called(id)
+	return args.Get(0).(map[model.MessageStatus]int64), args.Error(1)
+}
+
+func TestHandler_CreateCampaignWithCategory(t *testing.T) {
+	r, h, _ := setupRouter()
+	r.POST("/campaigns", h.CreateCampaign)
+	campaigns := new(MockCampaignRepository)
+	h.Campaigns = service.NewCampaignService(campaigns)
+
+	campaigns.On("Create", mock.MatchedBy(func(c *model.Campaign) bool {
+		return c.Category == "transactional"
+	})).Return(nil)
+
+	body := `{"name": "Order updates", "template": "Your order shipped", "audience": [{"to": "+905551234567"}], "category": "transactional"}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/campaigns", bytes.NewBufferString(body))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var campaign model.Campaign
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &campaign))
+	assert.Equal(t, "transactional", campaign.Category)
+
+	// an unknown category is rejected by the service
+	body = `{"name": "Order updates", "template": "Your order shipped", "audience": [{"to": "+905551234567"}], "category": "urgent"}`
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/campaigns", bytes.NewBufferString(body))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	campaigns.AssertNumberOfCalls(t, "Create", 1)
 }
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"insider-assessment/internal/model"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CreateCampaignRequest struct {
+	Name        string                    `json:"name" binding:"required"`
+	Template    string                    `json:"template" binding:"required"`
+	Audience    []model.CampaignRecipient `json:"audience" binding:"required"`
+	ScheduledAt *time.Time                `json:"scheduled_at"`
+	Category    string                    `json:"category,omitempty" enums:"transactional,marketing"` // copied to every message; defaults to marketing
+}
+
+// CreateCampaign godoc
+// @Summary Create a campaign
+// @Description Validates the template against every audience entry and stores the campaign as DRAFT. Nothing is sent until it is launched.
+// @Tags Campaigns
+// @Accept json
+// @Produce json
+// @Param campaign body CreateCampaignRequest true "Campaign"
+// @Success 201 {object} model.Campaign
+// @Failure 400 {object} map[string]string
+// @Router /campaigns [post]
+func (h *Handler) CreateCampaign(c *gin.Context) {
+	if h.Campaigns == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "campaigns not available"})
+		return
+	}
+
+	var req CreateCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	campaign := model.Campaign{
+		Name:        req.Name,
+		Template:    req.Template,
+		Audience:    req.Audience,
+		ScheduledAt: req.ScheduledAt,
+		Category:    req.Category,
+	}
+	if err := h.Campaigns.Create(&campaign); err != nil {
+		respondError(c, err, "campaign not found")
+		return
+	}
+	c.JSON(http.StatusCreated, campaign)
+}
+
+// GetCampaign godoc
+// @Summary Get a campaign with its progress
+// @Description Progress counts are computed from the campaign's messages.
+// @Tags Campaigns
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 200 {object} service.CampaignView
+// @Failure 404 {object} map[string]string
+// @Router /campaigns/{id} [get]
+func (h *Handler) GetCampaign(c *gin.Context) {
+	if h.Campaigns == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "campaigns not available"})
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	view, err := h.Campaigns.Get(id)
+	if err != nil {
+		respondError(c, err, "campaign not found")
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// LaunchCampaign godoc
+// @Summary Launch or resume a campaign
+// @Description Fans a DRAFT campaign out into pending messages, or resumes a PAUSED one. Messages are held until scheduled_at.
+// @Tags Campaigns
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 200 {object} model.Campaign
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /campaigns/{id}/launch [post]
+func (h *Handler) LaunchCampaign(c *gin.Context) {
+	h.transitionCampaign(c, h.Campaigns.Launch)
+}
+
+// PauseCampaign godoc
+// @Summary Pause a running campaign
+// @Tags Campaigns
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 200 {object} model.Campaign
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /campaigns/{id}/pause [post]
+func (h *Handler) PauseCampaign(c *gin.Context) {
+	h.transitionCampaign(c, h.Campaigns.Pause)
+}
+
+// CancelCampaign godoc
+// @Summary Cancel a campaign
+// @Description Pending messages of a cancelled campaign are never sent.
+// @Tags Campaigns
+// @Produce json
+// @Param id path string true "Campaign ID"
+// @Success 200 {object} model.Campaign
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /campaigns/{id}/cancel [post]
+func (h *Handler) CancelCampaign(c *gin.Context) {
+	h.transitionCampaign(c, h.Campaigns.Cancel)
+}
+
+func (h *Handler) transitionCampaign(c *gin.Context, transition func(uuid.UUID) (*model.Campaign, error)) {
+	if h.Campaigns == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "campaigns not available"})
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	campaign, err := transition(id)
+	if err != nil {
+		respondError(c, err, "campaign not found")
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
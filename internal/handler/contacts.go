@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"insider-assessment/internal/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ContactRequest struct {
+	Phone      string         `json:"phone" binding:"required" example:"+905551112233"`
+	Name       string         `json:"name" example:"Ayşe"`
+	Locale     string         `json:"locale" example:"tr-TR"`
+	Timezone   string         `json:"timezone" example:"Europe/Istanbul"` // IANA name; inferred from the number's country code when empty
+	Attributes map[string]any `json:"attributes" swaggertype:"object"`
+}
+
+func (r ContactRequest) contact() model.Contact {
+	return model.Contact{Phone: r.Phone, Name: r.Name, Locale: r.Locale, Timezone: r.Timezone, Attributes: r.Attributes}
+}
+
+type ContactQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+type ContactPage struct {
+	Contacts   []model.Contact `json:"contacts"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+type SegmentRequest struct {
+	Name       string                   `json:"name" binding:"required" example:"Istanbul gold members"`
+	Conditions []model.SegmentCondition `json:"conditions"` // all must hold; none matches every contact
+}
+
+// CreateContact godoc
+// @Summary Create a contact
+// @Description The number is normalized and must be unique. Attributes are free-form and can be matched by segments and used as template placeholders.
+// @Tags Contacts
+// @Accept json
+// @Produce json
+// @Param contact body ContactRequest true "Contact"
+// @Success 201 {object} model.Contact
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /contacts [post]
+func (h *Handler) CreateContact(c *gin.Context) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+
+	var req ContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact := req.contact()
+	if err := h.Contacts.CreateContact(&contact); err != nil {
+		respondError(c, err, "")
+		return
+	}
+	c.JSON(http.StatusCreated, contact)
+}
+
+// ListContacts godoc
+// @Summary List contacts
+// @Description Ordered by number and paginated with next_cursor.
+// @Tags Contacts
+// @Produce json
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Success 200 {object} ContactPage
+// @Failure 400 {object} map[string]string
+// @Router /contacts [get]
+func (h *Handler) ListContacts(c *gin.Context) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+
+	var query ContactQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contacts, next, err := h.Contacts.Contacts.List(query.Cursor, pageSize(query.Limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if contacts == nil {
+		contacts = []model.Contact{}
+	}
+	c.JSON(http.StatusOK, ContactPage{Contacts: contacts, NextCursor: next})
+}
+
+// GetContact godoc
+// @Summary Get a contact
+// @Tags Contacts
+// @Produce json
+// @Param id path string true "Contact ID"
+// @Success 200 {object} model.Contact
+// @Failure 404 {object} map[string]string
+// @Router /contacts/{id} [get]
+func (h *Handler) GetContact(c *gin.Context) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	contact, err := h.Contacts.Contacts.GetByID(id)
+	if err != nil {
+		respondError(c, err, "contact not found")
+		return
+	}
+	c.JSON(http.StatusOK, contact)
+}
+
+// UpdateContact godoc
+// @Summary Replace a contact
+// @Description Every field is replaced, attributes included.
+// @Tags Contacts
+// @Accept json
+// @Produce json
+// @Param id path string true "Contact ID"
+// @Param contact body ContactRequest true "Contact"
+// @Success 200 {object} model.Contact
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Another contact has the number"
+// @Router /contacts/{id} [put]
+func (h *Handler) UpdateContact(c *gin.Context) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact := req.contact()
+	contact.ID = id
+	if err := h.Contacts.UpdateContact(&contact); err != nil {
+		respondError(c, err, "contact not found")
+		return
+	}
+	c.JSON(http.StatusOK, contact)
+}
+
+// DeleteContact godoc
+// @Summary Delete a contact
+// @Description Messages already created for the contact are kept.
+// @Tags Contacts
+// @Param id path string true "Contact ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /contacts/{id} [delete]
+func (h *Handler) DeleteContact(c *gin.Context) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.Contacts.Contacts.Delete(id); err != nil {
+		respondError(c, err, "contact not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CreateSegment godoc
+// @Summary Create a segment
+// @Description A segment is a saved filter over contacts; a contact belongs to it when every condition holds. Fields are `locale`, `timezone` or `attributes.<key>`; operators are `eq`, `ne`, `in` (value is a list) and `exists` (no value).
+// @Tags Segments
+// @Accept json
+// @Produce json
+// @Param segment body SegmentRequest true "Segment"
+// @Success 201 {object} model.Segment
+// @Failure 400 {object} map[string]string
+// @Router /segments [post]
+func (h *Handler) CreateSegment(c *gin.Context) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+
+	var req SegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	segment := model.Segment{Name: req.Name, Conditions: req.Conditions}
+	if err := h.Contacts.CreateSegment(&segment); err != nil {
+		respondError(c, err, "")
+		return
+	}
+	c.JSON(http.StatusCreated, segment)
+}
+
+// ListSegments godoc
+// @Summary List segments
+// @Tags Segments
+// @Produce json
+// @Success 200 {array} model.Segment
+// @Router /segments [get]
+func (h *Handler) ListSegments(c *gin.Context) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+
+	segments, err := h.Contacts.Segments.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if segments == nil {
+		segments = []model.Segment{}
+	}
+	c.JSON(http.StatusOK, segments)
+}
+
+// GetSegment godoc
+// @Summary Get a segment
+// @Description Includes the number of contacts the segment matches right now.
+// @Tags Segments
+// @Produce json
+// @Param id path string true "Segment ID"
+// @Success 200 {object} service.SegmentView
+// @Failure 404 {object} map[string]string
+// @Router /segments/{id} [get]
+func (h *Handler) GetSegment(c *gin.Context) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	segment, err := h.Contacts.GetSegment(id)
+	if err != nil {
+		respondError(c, err, "segment not found")
+		return
+	}
+	c.JSON(http.StatusOK, segment)
+}
+
+// UpdateSegment godoc
+// @Summary Replace a segment
+// @Tags Segments
+// @Accept json
+// @Produce json
+// @Param id path string true "Segment ID"
+// @Param segment body SegmentRequest true "Segment"
+// @Success 200 {object} model.Segment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /segments/{id} [put]
+func (h *Handler) UpdateSegment(c *gin.Context) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req SegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	segment := model.Segment{ID: id, Name: req.Name, Conditions: req.Conditions}
+	if err := h.Contacts.UpdateSegment(&segment); err != nil {
+		respondError(c, err, "segment not found")
+		return
+	}
+	c.JSON(http.StatusOK, segment)
+}
+
+// DeleteSegment godoc
+// @Summary Delete a segment
+// @Description The contacts it matched are kept.
+// @Tags Segments
+// @Param id path string true "Segment ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /segments/{id} [delete]
+func (h *Handler) DeleteSegment(c *gin.Context) {
+	if !h.contactsAvailable(c) {
+		return
+	}
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.Contacts.Segments.Delete(id); err != nil {
+		respondError(c, err, "segment not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) contactsAvailable(c *gin.Context) bool {
+	if h.Contacts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "contacts not available"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateSubscriptionRequest struct {
+	URL    string            `json:"url" binding:"required" example:"https://example.com/hooks/sms"`
+	Events []model.EventType `json:"events" example:"message.sent,message.failed"` // empty subscribes to every event
+	Secret string            `json:"secret"`                                       // deliveries carry X-Event-Signature: sha256=HMAC(secret, body)
+}
+
+// CreateSubscription godoc
+// @Summary Register an endpoint for lifecycle events
+// @Description Events (`message.created`, `message.sent`, `message.failed`) are POSTed as JSON with `X-Event-Id` and `X-Event-Type` headers. Events are delivered at least once; failed deliveries are retried with exponential backoff.
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Param subscription body CreateSubscriptionRequest true "Subscription"
+// @Success 201 {object} model.EventSubscription
+// @Failure 400 {object} map[string]string
+// @Router /event-subscriptions [post]
+func (h *Handler) CreateSubscription(c *gin.Context) {
+	if !h.eventsAvailable(c) {
+		return
+	}
+
+	var req CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub := model.EventSubscription{URL: req.URL, Events: req.Events, Secret: req.Secret}
+	if err := service.ValidateSubscription(&sub); err != nil {
+		respondError(c, err, "")
+		return
+	}
+	if err := h.Events.Subscriptions.Create(&sub); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// ListSubscriptions godoc
+// @Summary List the registered event endpoints
+// @Tags Events
+// @Produce json
+// @Success 200 {array} model.EventSubscription
+// @Router /event-subscriptions [get]
+func (h *Handler) ListSubscriptions(c *gin.Context) {
+	if !h.eventsAvailable(c) {
+		return
+	}
+
+	subs, err := h.Events.Subscriptions.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if subs == nil {
+		subs = []model.EventSubscription{}
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// DeleteSubscription godoc
+// @Summary Remove an event endpoint
+// @Tags Events
+// @Param id path string true "Subscription ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /event-subscriptions/{id} [delete]
+func (h *Handler) DeleteSubscription(c *gin.Context) {
+	if !h.eventsAvailable(c) {
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.Events.Subscriptions.Delete(id); err != nil {
+		respondError(c, err, "subscription not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) eventsAvailable(c *gin.Context) bool {
+	if h.Events == nil || h.Events.Subscriptions == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "events not available"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"insider-assessment/internal/service"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// respondError maps repository and service errors to HTTP status codes
+func respondError(c *gin.Context, err error, notFoundMsg string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFoundMsg})
+	case errors.Is(err, repository.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case service.IsValidationError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrSuppressed):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// parseID reads a UUID path parameter, writing a 400 when it is malformed
+func parseID(c *gin.Context, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// actorFrom identifies who triggered an operation. There is no auth yet, so callers
+// name themselves with the X-Actor header; anonymous calls are recorded as "api".
+func actorFrom(c *gin.Context) string {
+	if actor := strings.TrimSpace(c.GetHeader("X-Actor")); actor != "" {
+		return actor
+	}
+	return "api"
+}
+
+// pageSize applies the default page size of the list endpoints
+func pageSize(limit int) int {
+	if limit <= 0 {
+		return 50
+	}
+	return limit
+}
+
+// messages returns the message repository bound to the request context so its queries join the request trace
+func (h *Handler) messages(c *gin.Context) repository.MessageRepository {
+	return h.Repo.WithContext(c.Request.Context())
+}
+
+// enqueue announces committed PENDING messages to the queue. The rows are already stored,
+// so a failure is only logged: the worker still finds them by polling.
+func (h *Handler) enqueue(c *gin.Context, ids ...uuid.UUID) {
+	if h.Queue == nil {
+		return
+	}
+	if err := h.Queue.Enqueue(c.Request.Context(), ids...); err != nil {
+		slog.Warn("failed to enqueue messages", "count", len(ids), "error", err)
+	}
+}
+
+// broadcast announces committed transitions from the given status to live listeners
+func (h *Handler) broadcast(from model.MessageStatus, msgs ...model.Message) {
+	if h.Scheduler == nil || h.Scheduler.Sender == nil {
+		return
+	}
+	updates := make([]service.StatusUpdate, len(msgs))
+	for i, msg := range msgs {
+		updates[i] = service.StatusUpdate{
+			MessageID:  msg.ID,
+			To:         msg.To,
+			CampaignID: msg.CampaignID,
+			From:       from,
+			Status:     msg.Status,
+		}
+	}
+	h.Scheduler.Sender.Updates.Publish(updates...)
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"insider-assessment/internal/service"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportMessages godoc
+// @Summary Import messages from a CSV or NDJSON file
+// @Description Streams the uploaded file row by row. Each row needs a `to` column and either a `content` column or the variables used by `template`.
+// @Description The `format` and `template` fields must be sent before the `file` part.
+// @Tags Imports
+// @Accept multipart/form-data
+// @Produce json
+// @Param format formData string false "csv or ndjson (detected from the file extension when omitted)"
+// @Param template formData string false "Content template with {{variable}} placeholders"
+// @Param file formData file true "Recipients file"
+// @Success 201 {object} model.ImportJob
+// @Failure 400 {object} map[string]string
+// @Router /imports [post]
+func (h *Handler) ImportMessages(c *gin.Context) {
+	if h.Importer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "imports not available"})
+		return
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var opts service.ImportOptions
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		switch part.FormName() {
+		case "format", "template":
+			val, err := io.ReadAll(io.LimitReader(part, 64*1024))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if part.FormName() == "format" {
+				opts.Format = strings.TrimSpace(string(val))
+			} else {
+				opts.Template = string(val)
+			}
+		case "file":
+			opts.Filename = part.FileName()
+			job, err := h.Importer.Import(part, opts)
+			if errors.Is(err, service.ErrUnsupportedFormat) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusCreated, job)
+			return
+		}
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+}
+
+// GetImport godoc
+// @Summary Get an import job
+// @Tags Imports
+// @Produce json
+// @Param id path string true "Import ID"
+// @Success 200 {object} model.ImportJob
+// @Failure 404 {object} map[string]string
+// @Router /imports/{id} [get]
+func (h *Handler) GetImport(c *gin.Context) {
+	if h.Importer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "imports not available"})
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	job, err := h.Importer.Jobs.GetByID(id)
+	if err != nil {
+		respondError(c, err, "import not found")
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// GetImportErrors godoc
+// @Summary Download the error report of an import
+// @Description Returns the rejected rows as CSV (row, to, reason).
+// @Tags Imports
+// @Produce text/csv
+// @Param id path string true "Import ID"
+// @Success 200 {string} string
+// @Failure 404 {object} map[string]string
+// @Router /imports/{id}/errors [get]
+func (h *Handler) GetImportErrors(c *gin.Context) {
+	if h.Importer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "imports not available"})
+		return
+	}
+
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.Importer.Jobs.GetByID(id); err != nil {
+		respondError(c, err, "import not found")
+		return
+	}
+
+	rowErrs, err := h.Importer.Jobs.GetErrors(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=import-%s-errors.csv", id))
+	c.Status(http.StatusOK)
+	if err := service.WriteImportErrorReport(c.Writer, rowErrs); err != nil {
+		c.Error(err)
+	}
+}
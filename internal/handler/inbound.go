@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"insider-assessment/internal/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InboundMessageRequest struct {
+	From     string `json:"from" binding:"required" example:"+905551112233"`
+	Content  string `json:"content" example:"STOP"`
+	RemoteID string `json:"remote_id" example:"mo-8f14e45f"` // provider's ID of the reply; a redelivery with the same ID returns the stored reply
+}
+
+type InboundMessageResponse struct {
+	Message model.InboundMessage `json:"message"`
+	Action  string               `json:"action" enums:"opted_out,opted_in,none"`
+}
+
+type ConversationQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// ReceiveInbound godoc
+// @Summary Receive a reply from a recipient
+// @Description Called by the SMS provider for every inbound (mobile-originated) message. The reply is stored and linked to the last message sent to the number. A reply consisting of an opt-out keyword (STOP, UNSUBSCRIBE, ...) suppresses the sender, an opt-in keyword (START, UNSTOP) lifts the suppression.
+// @Tags Inbound
+// @Accept json
+// @Produce json
+// @Param message body InboundMessageRequest true "Inbound message"
+// @Success 200 {object} InboundMessageResponse
+// @Failure 400 {object} map[string]string
+// @Router /inbound [post]
+func (h *Handler) ReceiveInbound(c *gin.Context) {
+	if !h.inboundAvailable(c) {
+		return
+	}
+
+	var req InboundMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg, action, err := h.Inbound.Receive(req.From, req.Content, req.RemoteID)
+	if err != nil {
+		respondError(c, err, "")
+		return
+	}
+	c.JSON(http.StatusOK, InboundMessageResponse{Message: *msg, Action: string(action)})
+}
+
+// GetConversation godoc
+// @Summary Two-way thread with a number
+// @Description Messages sent to the number and its replies, newest first. Entries are paginated with next_cursor.
+// @Tags Inbound
+// @Produce json
+// @Param phone path string true "Phone number"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Success 200 {object} service.Conversation
+// @Failure 400 {object} map[string]string
+// @Router /conversations/{phone} [get]
+func (h *Handler) GetConversation(c *gin.Context) {
+	if !h.inboundAvailable(c) {
+		return
+	}
+
+	var query ConversationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversation, err := h.Inbound.Conversation(c.Param("phone"), query.Cursor, pageSize(query.Limit))
+	if err != nil {
+		respondError(c, err, "")
+		return
+	}
+	c.JSON(http.StatusOK, conversation)
+}
+
+func (h *Handler) inboundAvailable(c *gin.Context) bool {
+	if h.Inbound == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "inbound messages not available"})
+		return false
+	}
+	return true
+}
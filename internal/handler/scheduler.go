@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"insider-assessment/internal/service"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpdateSchedulerRequest struct {
+	Interval  *string `json:"interval" example:"30s"`
+	BatchSize *int    `json:"batch_size" example:"10"`
+	Schedule  *string `json:"schedule" example:"*/5 * * * *"`                       // cron expression, "" goes back to the interval
+	Window    *string `json:"window" example:"Mon-Fri 09:00-21:00 Europe/Istanbul"` // send window, "" removes it
+}
+
+type RunSchedulerRequest struct {
+	BatchSize int `json:"batch_size" example:"50"` // overrides the configured batch size for this run
+}
+
+// GetScheduler godoc
+// @Summary Get the scheduler state
+// @Description Running state, current settings, the last tick with its result and the next planned tick.
+// @Description `leadership` shows whether this replica is the leader that sends, and which instance is.
+// @Tags Control
+// @Produce json
+// @Success 200 {object} service.SchedulerStatus
+// @Router /scheduler [get]
+func (h *Handler) GetScheduler(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Scheduler.Status(c.Request.Context()))
+}
+
+// UpdateScheduler godoc
+// @Summary Change the scheduler settings at runtime
+// @Description Omitted fields keep their value and the next tick is re-planned right away.
+// @Description `schedule` is a five-field cron expression (e.g. `*/5 * * * *`) that replaces the interval. `window` limits sending to e.g. `Mon-Fri 09:00-21:00 Europe/Istanbul`; messages outside it stay PENDING.
+// @Tags Control
+// @Accept json
+// @Produce json
+// @Param settings body UpdateSchedulerRequest true "Settings"
+// @Success 200 {object} service.SchedulerStatus
+// @Failure 400 {object} map[string]string
+// @Router /scheduler [patch]
+func (h *Handler) UpdateScheduler(c *gin.Context) {
+	var req UpdateSchedulerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings := service.SchedulerSettings{BatchSize: req.BatchSize, Schedule: req.Schedule, Window: req.Window}
+	if req.Interval != nil {
+		d, err := time.ParseDuration(*req.Interval)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid interval: " + err.Error()})
+			return
+		}
+		settings.Interval = &d
+	}
+
+	if err := h.Scheduler.Configure(settings); err != nil {
+		respondError(c, err, "")
+		return
+	}
+	c.JSON(http.StatusOK, h.Scheduler.Status(c.Request.Context()))
+}
+
+// RunScheduler godoc
+// @Summary Process one batch immediately
+// @Description Claims and sends one batch without waiting for the next tick and returns its result. Quiet hours still apply.
+// @Description The body is optional; `batch_size` overrides the configured size for this run only. Returns 409 while the scheduler is stopped, outside the send window or while a batch is in progress on this instance, and 503 on a follower replica or when the scheduler state can't be read.
+// @Tags Control
+// @Accept json
+// @Produce json
+// @Param run body RunSchedulerRequest false "Run options"
+// @Success 200 {object} service.BatchResult
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /scheduler/run [post]
+func (h *Handler) RunScheduler(c *gin.Context) {
+	var req RunSchedulerRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.Scheduler.RunNow(req.BatchSize, actorFrom(c))
+	switch {
+	case errors.Is(err, service.ErrCycleRunning), errors.Is(err, service.ErrNotRunning), errors.Is(err, service.ErrOutsideWindow):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrNotLeader), errors.Is(err, service.ErrStateUnknown):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		respondError(c, err, "")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
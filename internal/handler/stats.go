@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStats godoc
+// @Summary Queue statistics and throughput
+// @Description Counts by status, oldest pending age, sends in the last minute/hour, failure rate and webhook latency percentiles over the last hour. Cached for a few seconds.
+// @Tags System
+// @Produce json
+// @Success 200 {object} repository.Stats
+// @Router /stats [get]
+func (h *Handler) GetStats(c *gin.Context) {
+	if h.Stats == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "stats not available"})
+		return
+	}
+
+	stats, err := h.Stats.Get(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
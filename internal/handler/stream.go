@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment so proxies keep it open
+const streamKeepAlive = 15 * time.Second
+
+type StreamMessagesQuery struct {
+	Status     model.MessageStatus `form:"status"`
+	To         string              `form:"to"`
+	CampaignID string              `form:"campaign_id" binding:"omitempty,uuid"`
+}
+
+// StreamMessages godoc
+// @Summary Stream live status changes
+// @Description Server-Sent Events stream of status transitions: messages created, cancelled and retried through the API and every change the workers make, on all replicas when Redis is available. Each `status` event carries a StatusUpdate and its `id`; a client reconnecting with `Last-Event-ID` first receives the updates it missed, as far as they are still kept in memory. Idle streams send a comment every 15 seconds.
+// @Tags Messages
+// @Produce text/event-stream
+// @Param status query string false "New status" Enums(PENDING, PROCESSING, SENT, FAILED, CANCELLED, SUPPRESSED, DUPLICATE)
+// @Param to query string false "Recipient, in any format that normalizes to E.164"
+// @Param campaign_id query string false "Campaign ID"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Success 200 {object} service.StatusUpdate
+// @Failure 400 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /messages/stream [get]
+func (h *Handler) StreamMessages(c *gin.Context) {
+	updates := h.broadcaster(c)
+	if updates == nil {
+		return
+	}
+
+	var query StreamMessagesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := service.UpdateFilter{
+		Status: model.MessageStatus(strings.ToUpper(string(query.Status))),
+	}
+	if filter.Status != "" && !filter.Status.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown status %q", query.Status)})
+		return
+	}
+	if query.To != "" {
+		to, err := service.NormalizeRecipient(query.To)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.To = to
+	}
+	if query.CampaignID != "" {
+		campaignID := uuid.MustParse(query.CampaignID) // validated by binding
+		filter.CampaignID = &campaignID
+	}
+
+	var lastID uint64
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		var err error
+		if lastID, err = strconv.ParseUint(header, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Last-Event-ID"})
+			return
+		}
+	}
+
+	listener, replay := updates.Subscribe(filter, lastID)
+	defer listener.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // nginx would otherwise hold the events back
+	c.Status(http.StatusOK)
+	for _, u := range replay {
+		writeUpdate(c, u)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case u, ok := <-listener.C:
+			if !ok {
+				return // fell behind; the client resumes with Last-Event-ID
+			}
+			writeUpdate(c, u)
+		case <-keepAlive.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeUpdate(c *gin.Context, u service.StatusUpdate) {
+	data, _ := json.Marshal(u)
+	fmt.Fprintf(c.Writer, "id: %d\nevent: status\ndata: %s\n\n", u.ID, data)
+}
+
+// broadcaster returns the worker's live updates, writing a 503 when they aren't wired
+func (h *Handler) broadcaster(c *gin.Context) *service.Broadcaster {
+	if h.Scheduler == nil || h.Scheduler.Sender == nil || h.Scheduler.Sender.Updates == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "live updates not available"})
+		return nil
+	}
+	return h.Scheduler.Sender.Updates
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"insider-assessment/internal/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateSuppressionRequest struct {
+	Phone  string `json:"phone" binding:"required" example:"+905551112233"`
+	Reason string `json:"reason" example:"asked support to stop"`
+}
+
+type SuppressionQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+type SuppressionPage struct {
+	Suppressions []model.Suppression `json:"suppressions"`
+	NextCursor   string              `json:"next_cursor,omitempty"`
+}
+
+// CreateSuppression godoc
+// @Summary Suppress a recipient
+// @Description Opted-out numbers are rejected by POST /messages, and queued messages to them end as SUPPRESSED instead of being sent. Numbers are normalized, so formatting doesn't matter.
+// @Tags Suppressions
+// @Accept json
+// @Produce json
+// @Param suppression body CreateSuppressionRequest true "Suppression"
+// @Success 201 {object} model.Suppression
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /suppressions [post]
+func (h *Handler) CreateSuppression(c *gin.Context) {
+	if !h.suppressionsAvailable(c) {
+		return
+	}
+
+	var req CreateSuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	suppression, err := h.Suppressions.Add(req.Phone, req.Reason, model.SuppressionSourceAPI)
+	if err != nil {
+		respondError(c, err, "")
+		return
+	}
+	c.JSON(http.StatusCreated, suppression)
+}
+
+// ListSuppressions godoc
+// @Summary List suppressed recipients
+// @Description Ordered by number and paginated with next_cursor.
+// @Tags Suppressions
+// @Produce json
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Success 200 {object} SuppressionPage
+// @Failure 400 {object} map[string]string
+// @Router /suppressions [get]
+func (h *Handler) ListSuppressions(c *gin.Context) {
+	if !h.suppressionsAvailable(c) {
+		return
+	}
+
+	var query SuppressionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	suppressions, next, err := h.Suppressions.List(query.Cursor, pageSize(query.Limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if suppressions == nil {
+		suppressions = []model.Suppression{}
+	}
+	c.JSON(http.StatusOK, SuppressionPage{Suppressions: suppressions, NextCursor: next})
+}
+
+// DeleteSuppression godoc
+// @Summary Lift a suppression
+// @Tags Suppressions
+// @Param phone path string true "Suppressed number"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /suppressions/{phone} [delete]
+func (h *Handler) DeleteSuppression(c *gin.Context) {
+	if !h.suppressionsAvailable(c) {
+		return
+	}
+
+	if err := h.Suppressions.Remove(c.Param("phone")); err != nil {
+		respondError(c, err, "suppression not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) suppressionsAvailable(c *gin.Context) bool {
+	if h.Suppressions == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "suppressions not available"})
+		return false
+	}
+	return true
+}
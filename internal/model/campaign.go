@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CampaignStatus string
+
+const (
+	CampaignDraft     CampaignStatus = "DRAFT"
+	CampaignRunning   CampaignStatus = "RUNNING"
+	CampaignPaused    CampaignStatus = "PAUSED"
+	CampaignCancelled CampaignStatus = "CANCELLED"
+)
+
+// CampaignRecipient is a single audience entry with its template variables
+type CampaignRecipient struct {
+	To       string            `json:"to"`
+	Vars     map[string]string `json:"vars,omitempty"`
+	Timezone string            `json:"timezone,omitempty"` // IANA name; inferred from the number when empty
+}
+
+// Campaign fans out into one Message per audience entry when launched.
+// Its messages are only picked up while the campaign is RUNNING and ScheduledAt has passed.
+type Campaign struct {
+	ID          uuid.UUID           `gorm:"primaryKey;type:uuid;" json:"id"`
+	Name        string              `gorm:"not null" json:"name"`
+	Template    string              `gorm:"not null" json:"template"`
+	Category    string              `gorm:"size:32;not null;default:'marketing'" json:"category"` // copied to every message
+	Audience    []CampaignRecipient `gorm:"type:jsonb;serializer:json" json:"audience"`
+	ScheduledAt *time.Time          `json:"scheduled_at,omitempty"`
+	Status      CampaignStatus      `gorm:"default:'DRAFT';index" json:"status"`
+	LaunchedAt  *time.Time          `json:"launched_at,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// BeforeCreate generates a new UUID if not present
+func (c *Campaign) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	c.Category = NormalizeCategory(c.Category)
+	return nil
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Contact is a known recipient. Phone is normalized by phone.Normalize and unique.
+type Contact struct {
+	ID         uuid.UUID      `gorm:"primaryKey;type:uuid" json:"id"`
+	Phone      string         `gorm:"size:32;not null;uniqueIndex" json:"phone"`
+	Name       string         `json:"name,omitempty"`
+	Locale     string         `gorm:"size:35;index" json:"locale,omitempty" example:"tr-TR"`
+	Timezone   string         `gorm:"size:64" json:"timezone,omitempty" example:"Europe/Istanbul"` // copied to the messages sent to the contact
+	Attributes map[string]any `gorm:"type:jsonb;serializer:json;index:idx_contacts_attributes,type:gin" json:"attributes,omitempty" swaggertype:"object"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// BeforeCreate generates a new UUID if not present
+func (c *Contact) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// Segment condition operators
+const (
+	OpEquals    = "eq"
+	OpNotEquals = "ne"
+	OpIn        = "in"
+	OpExists    = "exists"
+)
+
+// SegmentCondition compares one contact field with a value. Field is "locale", "timezone"
+// or "attributes.<key>"; attribute values are compared with their JSON type, so 3 doesn't
+// match "3". In takes a list of values, Exists none.
+type SegmentCondition struct {
+	Field string `json:"field" example:"attributes.plan"`
+	Op    string `json:"op" enums:"eq,ne,in,exists"`
+	Value any    `json:"value,omitempty" swaggertype:"string" example:"premium"`
+}
+
+// Segment is a saved audience: the contacts matching all of its conditions at send time
+type Segment struct {
+	ID         uuid.UUID          `gorm:"primaryKey;type:uuid" json:"id"`
+	Name       string             `gorm:"not null" json:"name"`
+	Conditions []SegmentCondition `gorm:"type:jsonb;serializer:json" json:"conditions"` // empty matches every contact
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// BeforeCreate generates a new UUID if not present
+func (s *Segment) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EventType string
+
+const (
+	EventMessageCreated EventType = "message.created"
+	EventMessageSent    EventType = "message.sent"
+	EventMessageFailed  EventType = "message.failed"
+)
+
+// EventTypes lists every event subscribers can ask for
+var EventTypes = []EventType{EventMessageCreated, EventMessageSent, EventMessageFailed}
+
+// Event is a message lifecycle event as delivered to subscribers. ID is stable across
+// redeliveries so consumers can deduplicate.
+type Event struct {
+	ID         uuid.UUID `json:"id"`
+	Type       EventType `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       EventData `json:"data"`
+}
+
+// EventData describes the message an event is about
+type EventData struct {
+	MessageID  uuid.UUID     `json:"message_id"`
+	To         string        `json:"to"`
+	Status     MessageStatus `json:"status"`
+	CampaignID *uuid.UUID    `json:"campaign_id,omitempty"`
+	RemoteID   string        `json:"remote_id,omitempty"` // provider's message ID, on message.sent
+}
+
+// NewMessageEvent builds an event about msg as it is now
+func NewMessageEvent(eventType EventType, msg Message) Event {
+	return Event{
+		ID:         uuid.New(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data: EventData{
+			MessageID:  msg.ID,
+			To:         msg.To,
+			Status:     msg.Status,
+			CampaignID: msg.CampaignID,
+		},
+	}
+}
+
+// EventSubscription is a customer endpoint that receives lifecycle events over HTTP
+type EventSubscription struct {
+	ID        uuid.UUID   `gorm:"primaryKey;type:uuid;" json:"id"`
+	URL       string      `gorm:"not null" json:"url"`
+	Events    []EventType `gorm:"type:jsonb;serializer:json" json:"events"` // empty means every event
+	Secret    string      `json:"-"`                                        // signs deliveries when set
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// BeforeCreate generates a new UUID if not present
+func (s *EventSubscription) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Wants reports whether the subscription receives events of the given type
+func (s *EventSubscription) Wants(eventType EventType) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, t := range s.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ImportStatus string
+
+const (
+	ImportProcessing ImportStatus = "PROCESSING"
+	ImportCompleted  ImportStatus = "COMPLETED"
+	ImportFailed     ImportStatus = "FAILED"
+)
+
+// ImportJob tracks a single bulk upload of recipients
+type ImportJob struct {
+	ID           uuid.UUID    `gorm:"primaryKey;type:uuid;" json:"id"`
+	Filename     string       `json:"filename"`
+	Format       string       `gorm:"not null" json:"format"`
+	Status       ImportStatus `gorm:"default:'PROCESSING';index" json:"status"`
+	TotalRows    int          `json:"total_rows"`
+	ImportedRows int          `json:"imported_rows"`
+	FailedRows   int          `json:"failed_rows"`
+	Error        string       `json:"error,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	CompletedAt  *time.Time   `json:"completed_at,omitempty"`
+}
+
+// ImportRowError is a single rejected row of an import, used for the error report
+type ImportRowError struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	ImportJobID uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	RowNumber   int       `json:"row"`
+	To          string    `json:"to"`
+	Reason      string    `json:"reason"`
+}
+
+// BeforeCreate generates a new UUID if not present
+func (j *ImportJob) BeforeCreate(tx *gorm.DB) (err error) {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
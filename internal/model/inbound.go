@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InboundMessage is a reply a recipient sent us (mobile-originated), as reported by the provider
+type InboundMessage struct {
+	ID         uuid.UUID  `gorm:"primaryKey;type:uuid" json:"id"`
+	From       string     `gorm:"size:32;not null;index:idx_inbound_from_received,priority:1" json:"from"` // normalized by phone.Normalize
+	Content    string     `gorm:"not null" json:"content"`
+	RemoteID   string     `gorm:"size:128;uniqueIndex:idx_inbound_remote_id,where:remote_id <> ''" json:"remote_id,omitempty"` // provider's ID; a redelivered reply is stored once
+	InReplyTo  *uuid.UUID `gorm:"type:uuid;index" json:"in_reply_to,omitempty"`                                                // the last message sent to the number before the reply
+	ReceivedAt time.Time  `gorm:"not null;index:idx_inbound_from_received,priority:2" json:"received_at"`
+}
+
+// BeforeCreate generates a new UUID if not present
+func (m *InboundMessage) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	if m.ReceivedAt.IsZero() {
+		m.ReceivedAt = time.Now()
+	}
+	return nil
+}
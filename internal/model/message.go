@@ -1,7 +1,11 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"insider-assessment/pkg/phone"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,19 +15,66 @@ import (
 type MessageStatus string
 
 const (
-	StatusPending MessageStatus = "PENDING"
-	StatusSent    MessageStatus = "SENT"
-	StatusFailed  MessageStatus = "FAILED"
+	StatusPending    MessageStatus = "PENDING"
+	StatusProcessing MessageStatus = "PROCESSING" // claimed by a worker, webhook call in flight
+	StatusSent       MessageStatus = "SENT"
+	StatusFailed     MessageStatus = "FAILED"
+	StatusCancelled  MessageStatus = "CANCELLED"
+	StatusSuppressed MessageStatus = "SUPPRESSED" // the recipient opted out before it was sent
+	StatusDuplicate  MessageStatus = "DUPLICATE"  // the same content already went to the recipient within the dedupe window
 )
 
+// Valid reports whether s is one of the statuses above
+func (s MessageStatus) Valid() bool {
+	switch s {
+	case StatusPending, StatusProcessing, StatusSent, StatusFailed, StatusCancelled, StatusSuppressed, StatusDuplicate:
+		return true
+	}
+	return false
+}
+
+// Message categories. Quiet hours are configured per category; transactional messages
+// (codes, alerts) are usually exempt while marketing ones are not.
+const (
+	CategoryTransactional = "transactional"
+	CategoryMarketing     = "marketing"
+)
+
+// NormalizeCategory lowercases a category. Messages without one are treated as marketing,
+// so forgetting it never exempts a message from the stricter quiet hours.
+func NormalizeCategory(category string) string {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		return CategoryMarketing
+	}
+	return category
+}
+
+// MaxContentLength is the longest content a single SMS may carry
+const MaxContentLength = 160
+
+// The composite indexes back the keyset pagination of the message query API
 type Message struct {
-	ID        uuid.UUID     `gorm:"primaryKey;type:uuid;" json:"id"`
-	To        string        `gorm:"not null" json:"to"`
-	Content   string        `gorm:"not null" json:"content"`
-	Status    MessageStatus `gorm:"default:'PENDING';index" json:"status"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
-	SentAt    *time.Time    `json:"sent_at,omitempty"`
+	ID          uuid.UUID     `gorm:"primaryKey;type:uuid;index:idx_messages_created_id,priority:2;index:idx_messages_status_created_id,priority:3" json:"id"`
+	To          string        `gorm:"not null;index" json:"to"`
+	Content     string        `gorm:"not null" json:"content"`
+	Status      MessageStatus `gorm:"default:'PENDING';index:idx_messages_status_created_id,priority:1" json:"status"`
+	CampaignID  *uuid.UUID    `gorm:"type:uuid;index" json:"campaign_id,omitempty"`
+	CreatedAt   time.Time     `gorm:"index:idx_messages_created_id,priority:1;index:idx_messages_status_created_id,priority:2" json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	SentAt      *time.Time    `gorm:"index;index:idx_messages_content_hash_sent,priority:2" json:"sent_at,omitempty"`
+	RetryCount  int           `gorm:"default:0" json:"retry_count"`
+	RetriedBy   string        `json:"retried_by,omitempty"`
+	RetriedAt   *time.Time    `json:"retried_at,omitempty"`
+	Category    string        `gorm:"size:32;not null;default:'marketing'" json:"category"`
+	Timezone    string        `gorm:"size:64" json:"timezone,omitempty"`                                // recipient's IANA timezone, inferred from the number when empty
+	NotBefore   *time.Time    `gorm:"index" json:"not_before,omitempty"`                                // the worker doesn't claim the message before this time
+	ClaimedAt   *time.Time    `gorm:"index" json:"claimed_at,omitempty"`                                // start of the lease of the worker holding it in PROCESSING
+	TraceID     string        `gorm:"size:32;index" json:"trace_id,omitempty"`                          // trace of the API call that created the message
+	SpanID      string        `gorm:"size:16" json:"-"`                                                 // the send span continues the trace from here
+	TraceFlags  uint8         `gorm:"not null;default:0" json:"-"`                                      // W3C trace flags of that span, so the send keeps its sampling decision
+	ContentHash string        `gorm:"size:64;index:idx_messages_content_hash_sent,priority:1" json:"-"` // see ContentHash
+	DuplicateOf *uuid.UUID    `gorm:"type:uuid" json:"duplicate_of,omitempty"`                          // the sent message a DUPLICATE repeats
 }
 
 // BeforeCreate generates a new UUID if not present
@@ -31,12 +82,21 @@ func (m *Message) BeforeCreate(tx *gorm.DB) (err error) {
 	if m.ID == uuid.Nil {
 		m.ID = uuid.New()
 	}
+	m.Category = NormalizeCategory(m.Category)
+	m.ContentHash = ContentHash(m.To, m.Content)
 	return nil
 }
 
+// ContentHash identifies what a message says to whom, for the dedupe window: the SHA-256
+// of the normalized number and the content with its whitespace collapsed
+func ContentHash(to, content string) string {
+	sum := sha256.Sum256([]byte(phone.Normalize(to) + "\x00" + strings.Join(strings.Fields(content), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
 // BeforeSave is a GORM hook to validate character limit
 func (m *Message) BeforeSave(tx *gorm.DB) (err error) {
-	if len(m.Content) > 160 {
+	if len(m.Content) > MaxContentLength {
 		return errors.New("message content exceeds 160 characters")
 	}
 	return nil
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent is a lifecycle event stored in the same transaction as the change it describes.
+// The relay publishes it until it is delivered, so a crash between the write and the publish
+// delays the event instead of losing it. DeliveredTo records the targets that already got it,
+// so a retry only goes to the ones that failed.
+type OutboxEvent struct {
+	ID            uint64     `gorm:"primaryKey" json:"id"` // publish order
+	EventID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"event_id"`
+	Type          EventType  `gorm:"size:32;not null" json:"type"`
+	Event         Event      `gorm:"type:jsonb;serializer:json;not null" json:"event"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_due,where:delivered_at IS NULL" json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	DeliveredTo   []string   `gorm:"type:jsonb;serializer:json" json:"delivered_to,omitempty"` // TargetPubSub and subscription IDs
+	DeliveredAt   *time.Time `gorm:"index" json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TargetPubSub names the Redis Pub/Sub channel in OutboxEvent.DeliveredTo
+const TargetPubSub = "pubsub"
+
+// NewOutboxEvent wraps an event for the outbox, due right away
+func NewOutboxEvent(event Event) OutboxEvent {
+	return OutboxEvent{
+		EventID:       event.ID,
+		Type:          event.Type,
+		Event:         event,
+		NextAttemptAt: event.OccurredAt,
+	}
+}
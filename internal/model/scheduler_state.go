@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// SchedulerStateID is the primary key of the single scheduler_states row
+const SchedulerStateID = 1
+
+// SchedulerState is the desired, cluster-wide scheduler state. Every replica reads it
+// before each tick, so a stop issued on one instance halts sending everywhere.
+type SchedulerState struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	Paused    bool      `gorm:"not null;default:false" json:"paused"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
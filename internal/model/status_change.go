@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusChange is one entry of a message's lifecycle timeline
+type StatusChange struct {
+	ID         uint          `gorm:"primaryKey" json:"-"`
+	MessageID  uuid.UUID     `gorm:"type:uuid;not null;index" json:"-"`
+	Event      string        `gorm:"not null" json:"event"`
+	FromStatus MessageStatus `json:"from,omitempty"`
+	ToStatus   MessageStatus `gorm:"not null" json:"status"`
+	Actor      string        `json:"actor,omitempty"`
+	LatencyMs  *int64        `json:"latency_ms,omitempty"` // webhook round trip for sent/failed
+	CreatedAt  time.Time     `gorm:"index" json:"at"`
+}
+
+const (
+	// EventReleased names the return of a message to PENDING after its claim expired
+	EventReleased = "released"
+	// EventReclaimed names the takeover of a message whose claim expired by another worker
+	EventReclaimed = "reclaimed"
+)
+
+// TransitionEvent names a status change for the timeline
+func TransitionEvent(from, to MessageStatus) string {
+	switch to {
+	case StatusPending:
+		switch from {
+		case "":
+			return "created"
+		case StatusProcessing:
+			return "deferred"
+		}
+		return "retried"
+	case StatusProcessing:
+		return "claimed"
+	case StatusSent:
+		return "sent"
+	case StatusFailed:
+		return "failed"
+	case StatusCancelled:
+		return "cancelled"
+	case StatusSuppressed:
+		return "suppressed"
+	case StatusDuplicate:
+		return "duplicate"
+	}
+	return string(to)
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Suppression sources
+const (
+	SuppressionSourceAPI     = "api"
+	SuppressionSourceInbound = "inbound" // the recipient replied with an opt-out keyword
+)
+
+// Suppression is a recipient who opted out and must not be messaged. Phone holds the
+// number as normalized by phone.Normalize.
+type Suppression struct {
+	Phone     string    `gorm:"primaryKey;size:32" json:"phone"`
+	Reason    string    `json:"reason,omitempty"`
+	Source    string    `gorm:"size:32;not null" json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
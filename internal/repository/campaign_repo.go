@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"insider-assessment/internal/model"
+	"insider-assessment/pkg/metrics"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CampaignRepository interface {
+	Create(campaign *model.Campaign) error
+	GetByID(id uuid.UUID) (*model.Campaign, error)
+	Launch(id uuid.UUID, messages []model.Message) error
+	Transition(id uuid.UUID, from []model.CampaignStatus, to model.CampaignStatus) error
+	Cancel(id uuid.UUID) error
+	CountMessages(id uuid.UUID) (map[model.MessageStatus]int64, error)
+}
+
+type campaignRepository struct {
+	DB *gorm.DB
+}
+
+func NewCampaignRepository(db *gorm.DB) CampaignRepository {
+	return &campaignRepository{DB: db}
+}
+
+func (r *campaignRepository) Create(campaign *model.Campaign) error {
+	return r.DB.Create(campaign).Error
+}
+
+func (r *campaignRepository) GetByID(id uuid.UUID) (*model.Campaign, error) {
+	var campaign model.Campaign
+	if err := r.DB.First(&campaign, "id = ?", id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &campaign, nil
+}
+
+// Launch moves a DRAFT campaign to RUNNING and inserts its messages in the same transaction,
+// so a campaign is never fanned out twice.
+func (r *campaignRepository) Launch(id uuid.UUID, messages []model.Message) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Campaign{}).
+			Where("id = ? AND status = ?", id, model.CampaignDraft).
+			Updates(map[string]interface{}{
+				"status":      model.CampaignRunning,
+				"launched_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return r.missingOrConflict(tx, id)
+		}
+
+		if len(messages) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(&messages, 500).Error; err != nil {
+			return err
+		}
+		return writeOutbox(tx, createdEvents(messages)...)
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.MessagesEnqueued.WithLabelValues(metrics.ChannelSMS).Add(float64(len(messages)))
+	return nil
+}
+
+// Transition changes the campaign status if it currently is one of from
+func (r *campaignRepository) Transition(id uuid.UUID, from []model.CampaignStatus, to model.CampaignStatus) error {
+	result := r.DB.Model(&model.Campaign{}).
+		Where("id = ? AND status IN ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOrConflict(r.DB, id)
+	}
+	return nil
+}
+
+// Cancel marks the campaign CANCELLED together with its still pending messages
+func (r *campaignRepository) Cancel(id uuid.UUID) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		from := []model.CampaignStatus{model.CampaignDraft, model.CampaignRunning, model.CampaignPaused}
+		result := tx.Model(&model.Campaign{}).
+			Where("id = ? AND status IN ?", id, from).
+			Update("status", model.CampaignCancelled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return r.missingOrConflict(tx, id)
+		}
+
+		var cancelled []model.Message
+		err := tx.Model(&cancelled).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "status"}}}).
+			Where("campaign_id = ? AND status = ?", id, model.StatusPending).
+			Update("status", model.StatusCancelled).Error
+		if err != nil {
+			return err
+		}
+		return recordChanges(tx, cancelled, model.StatusPending, "campaign")
+	})
+}
+
+// CountMessages returns the campaign's message counts grouped by status
+func (r *campaignRepository) CountMessages(id uuid.UUID) (map[model.MessageStatus]int64, error) {
+	var rows []struct {
+		Status model.MessageStatus
+		Count  int64
+	}
+	err := r.DB.Model(&model.Message{}).
+		Select("status, COUNT(*) AS count").
+		Where("campaign_id = ?", id).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[model.MessageStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *campaignRepository) missingOrConflict(tx *gorm.DB, id uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&model.Campaign{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrConflict
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"insider-assessment/internal/model"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ContactRepository interface {
+	Create(contact *model.Contact) error
+	GetByID(id uuid.UUID) (*model.Contact, error)
+	Update(contact *model.Contact) error
+	Delete(id uuid.UUID) error
+	List(cursor string, limit int) ([]model.Contact, string, error)
+	Match(conditions []model.SegmentCondition, afterPhone string, limit int) ([]model.Contact, error)
+	Count(conditions []model.SegmentCondition) (int64, error)
+}
+
+type contactRepository struct {
+	DB *gorm.DB
+}
+
+func NewContactRepository(db *gorm.DB) ContactRepository {
+	return &contactRepository{DB: db}
+}
+
+// Create stores the contact, returning ErrConflict when its number is already a contact
+func (r *contactRepository) Create(contact *model.Contact) error {
+	result := r.DB.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "phone"}}, DoNothing: true}).Create(contact)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *contactRepository) GetByID(id uuid.UUID) (*model.Contact, error) {
+	var contact model.Contact
+	if err := r.DB.First(&contact, "id = ?", id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &contact, nil
+}
+
+// Update replaces every field of the contact. It returns ErrConflict when the new number
+// belongs to another contact.
+func (r *contactRepository) Update(contact *model.Contact) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		err := tx.Model(&model.Contact{}).Where("phone = ? AND id <> ?", contact.Phone, contact.ID).Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrConflict
+		}
+
+		result := tx.Model(contact).
+			Clauses(clause.Returning{}).
+			Select("phone", "name", "locale", "timezone", "attributes", "updated_at").
+			Updates(contact)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *contactRepository) Delete(id uuid.UUID) error {
+	result := r.DB.Delete(&model.Contact{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List pages through the contacts ordered by number. The cursor is the last number of the
+// previous page; an empty next cursor means there are no more pages.
+func (r *contactRepository) List(cursor string, limit int) ([]model.Contact, string, error) {
+	query := r.DB.Order("phone ASC").Limit(limit + 1)
+	if cursor != "" {
+		query = query.Where("phone > ?", cursor)
+	}
+
+	var page []model.Contact
+	if err := query.Find(&page).Error; err != nil {
+		return nil, "", err
+	}
+	if len(page) <= limit {
+		return page, "", nil
+	}
+	page = page[:limit]
+	return page, page[limit-1].Phone, nil
+}
+
+// Match returns up to limit contacts matching every condition whose number sorts after
+// afterPhone, ordered by number, so a segment can be walked in chunks
+func (r *contactRepository) Match(conditions []model.SegmentCondition, afterPhone string, limit int) ([]model.Contact, error) {
+	query, err := applyConditions(r.DB.Model(&model.Contact{}), conditions)
+	if err != nil {
+		return nil, err
+	}
+	if afterPhone != "" {
+		query = query.Where("phone > ?", afterPhone)
+	}
+
+	var contacts []model.Contact
+	err = query.Order("phone ASC").Limit(limit).Find(&contacts).Error
+	return contacts, err
+}
+
+// Count returns how many contacts match every condition
+func (r *contactRepository) Count(conditions []model.SegmentCondition) (int64, error) {
+	query, err := applyConditions(r.DB.Model(&model.Contact{}), conditions)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = query.Count(&count).Error
+	return count, err
+}
+
+// segmentColumns are the contact columns conditions may name directly
+var segmentColumns = map[string]bool{"locale": true, "timezone": true}
+
+// applyConditions adds a WHERE clause per condition. Attribute comparisons use JSONB
+// containment, which the GIN index on attributes serves and which compares JSON types.
+func applyConditions(query *gorm.DB, conditions []model.SegmentCondition) (*gorm.DB, error) {
+	for _, cond := range conditions {
+		if key, ok := strings.CutPrefix(cond.Field, "attributes."); ok && key != "" {
+			var err error
+			if query, err = applyAttributeCondition(query, key, cond); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !segmentColumns[cond.Field] {
+			return nil, fmt.Errorf("unknown field %q", cond.Field)
+		}
+
+		column := clause.Column{Name: cond.Field}
+		switch cond.Op {
+		case model.OpEquals:
+			query = query.Where("? = ?", column, cond.Value)
+		case model.OpNotEquals:
+			query = query.Where("? <> ?", column, cond.Value)
+		case model.OpIn:
+			query = query.Where("? IN ?", column, cond.Value)
+		case model.OpExists:
+			query = query.Where("? <> ''", column)
+		default:
+			return nil, fmt.Errorf("unknown operator %q", cond.Op)
+		}
+	}
+	return query, nil
+}
+
+func applyAttributeCondition(query *gorm.DB, key string, cond model.SegmentCondition) (*gorm.DB, error) {
+	contains := func(value any) (string, error) {
+		doc, err := json.Marshal(map[string]any{key: value})
+		return string(doc), err
+	}
+
+	switch cond.Op {
+	case model.OpEquals, model.OpNotEquals:
+		doc, err := contains(cond.Value)
+		if err != nil {
+			return nil, err
+		}
+		if cond.Op == model.OpEquals {
+			return query.Where("attributes @> ?::jsonb", doc), nil
+		}
+		return query.Where("NOT COALESCE(attributes @> ?::jsonb, false)", doc), nil
+	case model.OpIn:
+		values, ok := cond.Value.([]any)
+		if !ok || len(values) == 0 {
+			return nil, errors.New("in needs a non-empty list")
+		}
+		matches := make([]string, len(values))
+		docs := make([]interface{}, len(values))
+		for i, value := range values {
+			doc, err := contains(value)
+			if err != nil {
+				return nil, err
+			}
+			matches[i], docs[i] = "attributes @> ?::jsonb", doc
+		}
+		return query.Where("("+strings.Join(matches, " OR ")+")", docs...), nil
+	case model.OpExists:
+		return query.Where("jsonb_exists(attributes, ?)", key), nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", cond.Op)
+}
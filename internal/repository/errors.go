@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound is returned by lookups when no row matches
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a row exists but is not in a state that allows the change
+	ErrConflict = errors.New("record is not in the expected state")
+)
+
+// translateError maps GORM errors to repository errors so callers don't depend on GORM
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"insider-assessment/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EventSubscriptionRepository interface {
+	Create(sub *model.EventSubscription) error
+	List() ([]model.EventSubscription, error)
+	Delete(id uuid.UUID) error
+}
+
+type eventSubscriptionRepository struct {
+	DB *gorm.DB
+}
+
+func NewEventSubscriptionRepository(db *gorm.DB) EventSubscriptionRepository {
+	return &eventSubscriptionRepository{DB: db}
+}
+
+func (r *eventSubscriptionRepository) Create(sub *model.EventSubscription) error {
+	return r.DB.Create(sub).Error
+}
+
+// List returns every subscription, oldest first
+func (r *eventSubscriptionRepository) List() ([]model.EventSubscription, error) {
+	var subs []model.EventSubscription
+	err := r.DB.Order("created_at ASC").Find(&subs).Error
+	return subs, err
+}
+
+func (r *eventSubscriptionRepository) Delete(id uuid.UUID) error {
+	result := r.DB.Delete(&model.EventSubscription{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"insider-assessment/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ImportRepository interface {
+	Create(job *model.ImportJob) error
+	Update(job *model.ImportJob) error
+	Fail(id uuid.UUID, reason string) error
+	GetByID(id uuid.UUID) (*model.ImportJob, error)
+	AddErrors(errs []model.ImportRowError) error
+	GetErrors(jobID uuid.UUID) ([]model.ImportRowError, error)
+}
+
+type importRepository struct {
+	DB *gorm.DB
+}
+
+func NewImportRepository(db *gorm.DB) ImportRepository {
+	return &importRepository{DB: db}
+}
+
+func (r *importRepository) Create(job *model.ImportJob) error {
+	return r.DB.Create(job).Error
+}
+
+func (r *importRepository) Update(job *model.ImportJob) error {
+	return r.DB.Save(job).Error
+}
+
+// Fail marks a job that is still PROCESSING as FAILED, touching only its status columns
+func (r *importRepository) Fail(id uuid.UUID, reason string) error {
+	return r.DB.Model(&model.ImportJob{}).
+		Where("id = ? AND status = ?", id, model.ImportProcessing).
+		Updates(map[string]interface{}{
+			"status":       model.ImportFailed,
+			"error":        reason,
+			"completed_at": gorm.Expr("NOW()"),
+		}).Error
+}
+
+func (r *importRepository) GetByID(id uuid.UUID) (*model.ImportJob, error) {
+	var job model.ImportJob
+	if err := r.DB.First(&job, "id = ?", id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &job, nil
+}
+
+func (r *importRepository) AddErrors(errs []model.ImportRowError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return r.DB.Create(&errs).Error
+}
+
+func (r *importRepository) GetErrors(jobID uuid.UUID) ([]model.ImportRowError, error) {
+	var errs []model.ImportRowError
+	result := r.DB.Where("import_job_id = ?", jobID).Order("row_number ASC").Find(&errs)
+	return errs, result.Error
+}
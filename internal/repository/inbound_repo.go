@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+	"insider-assessment/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InboundRepository interface {
+	Create(msg *model.InboundMessage) (bool, error)
+	ByRemoteID(remoteID string) (*model.InboundMessage, error)
+	LastSent(recipients []string) (*uuid.UUID, error)
+	Replies(from string, before *Position, limit int) ([]model.InboundMessage, error)
+	Sent(recipients []string, before *Position, limit int) ([]model.Message, error)
+}
+
+type inboundRepository struct {
+	DB *gorm.DB
+}
+
+func NewInboundRepository(db *gorm.DB) InboundRepository {
+	return &inboundRepository{DB: db}
+}
+
+// Create stores the reply and reports whether it is new. A reply the provider already
+// delivered under the same remote ID is not stored again.
+func (r *inboundRepository) Create(msg *model.InboundMessage) (bool, error) {
+	result := r.DB.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "remote_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "remote_id <> ''"}}},
+		DoNothing:   true,
+	}).Create(msg)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ByRemoteID returns the reply the provider delivered under the remote ID
+func (r *inboundRepository) ByRemoteID(remoteID string) (*model.InboundMessage, error) {
+	var msg model.InboundMessage
+	if err := r.DB.Where("remote_id = ?", remoteID).Take(&msg).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &msg, nil
+}
+
+// LastSent returns the ID of the message most recently sent to any of the numbers, nil if none was
+func (r *inboundRepository) LastSent(recipients []string) (*uuid.UUID, error) {
+	var msg model.Message
+	err := r.DB.Select("id").
+		Where(`"to" IN ? AND status = ?`, recipients, model.StatusSent).
+		Order("sent_at DESC").
+		Take(&msg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg.ID, nil
+}
+
+// Replies returns the newest replies from the number received before the given position
+func (r *inboundRepository) Replies(from string, before *Position, limit int) ([]model.InboundMessage, error) {
+	query := r.DB.Where(`"from" = ?`, from)
+	if before != nil {
+		query = query.Where("(received_at, id) < (?, ?)", before.At, before.ID)
+	}
+	var replies []model.InboundMessage
+	err := query.Order("received_at DESC").Order("id DESC").Limit(limit).Find(&replies).Error
+	return replies, err
+}
+
+// Sent returns the newest messages sent to any of the numbers before the given position
+func (r *inboundRepository) Sent(recipients []string, before *Position, limit int) ([]model.Message, error) {
+	query := r.DB.Where(`"to" IN ? AND status = ?`, recipients, model.StatusSent)
+	if before != nil {
+		query = query.Where("(sent_at, id) < (?, ?)", before.At, before.ID)
+	}
+	var sent []model.Message
+	err := query.Order("sent_at DESC").Order("id DESC").Limit(limit).Find(&sent).Error
+	return sent, err
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// SchedulerLockKey is the advisory lock key the scheduler replicas compete for
+const SchedulerLockKey int64 = 7_340_021
+
+// LeaderLock is a cluster-wide lock held by at most one instance at a time
+type LeaderLock interface {
+	// TryAcquire takes the lock if it is free and reports whether this instance holds it
+	TryAcquire(ctx context.Context) (bool, error)
+	// Check verifies the lock is still held; an error means leadership is lost
+	Check(ctx context.Context) error
+	// Release gives the lock up
+	Release(ctx context.Context) error
+	// Holder returns the identity of the instance holding the lock, or "" when nobody does
+	Holder(ctx context.Context) (string, error)
+}
+
+// advisoryLock is a session-level Postgres advisory lock. It is bound to one pooled
+// connection which is kept out of the pool while the lock is held; if that session dies,
+// Postgres releases the lock and another replica can take it.
+type advisoryLock struct {
+	DB       *gorm.DB
+	Key      int64
+	Identity string
+	conn     *sql.Conn
+}
+
+func NewAdvisoryLock(db *gorm.DB, key int64, identity string) LeaderLock {
+	return &advisoryLock{DB: db, Key: key, Identity: identity}
+}
+
+func (l *advisoryLock) TryAcquire(ctx context.Context) (bool, error) {
+	if l.conn != nil {
+		return true, l.Check(ctx)
+	}
+
+	sqlDB, err := l.DB.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.Key).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, err
+	}
+	if !acquired {
+		return false, conn.Close()
+	}
+
+	// the session name lets every replica see who the leader is through pg_stat_activity
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('application_name', $1, false)", l.Identity); err != nil {
+		discard(conn)
+		return false, err
+	}
+	l.conn = conn
+	return true, nil
+}
+
+func (l *advisoryLock) Check(ctx context.Context) error {
+	if l.conn == nil {
+		return errors.New("lock not held")
+	}
+
+	var held bool
+	err := l.conn.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM pg_locks WHERE locktype = 'advisory' AND pid = pg_backend_pid()
+			AND classid = ($1::bigint >> 32)::oid AND objid = ($1::bigint & 4294967295)::oid AND objsubid = 1 AND granted)`,
+		l.Key).Scan(&held)
+	if err == nil && !held {
+		err = errors.New("advisory lock no longer held")
+	}
+	if err != nil {
+		// never hand a session that may still hold the lock back to the pool
+		discard(l.conn)
+		l.conn = nil
+	}
+	return err
+}
+
+func (l *advisoryLock) Release(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+	conn := l.conn
+	l.conn = nil
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.Key); err != nil {
+		discard(conn)
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "RESET application_name"); err != nil {
+		discard(conn)
+		return err
+	}
+	return conn.Close()
+}
+
+func (l *advisoryLock) Holder(ctx context.Context) (string, error) {
+	var holder string
+	err := l.DB.WithContext(ctx).Raw(
+		`SELECT a.application_name FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.classid = (?::bigint >> 32)::oid AND l.objid = (?::bigint & 4294967295)::oid
+			AND l.objsubid = 1 AND l.granted`,
+		l.Key, l.Key).Scan(&holder).Error
+	return holder, err
+}
+
+// discard closes conn's session instead of returning it to the pool
+func discard(conn *sql.Conn) {
+	conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"insider-assessment/internal/model"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// MessageFilter describes a page of messages. Zero values don't filter.
+type MessageFilter struct {
+	Status        model.MessageStatus
+	To            string
+	CampaignID    *uuid.UUID
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	SentAfter     *time.Time
+	SentBefore    *time.Time
+	Descending    bool   // newest first, ordered by created_at then id
+	Cursor        string // NextCursor of the previous page
+	Limit         int
+}
+
+// List returns one page of messages using keyset pagination on (created_at, id).
+// The returned cursor is empty on the last page.
+func (r *messageRepository) List(filter MessageFilter) ([]model.Message, string, error) {
+	query := r.DB.Model(&model.Message{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.To != "" {
+		query = query.Where(`"to" = ?`, filter.To)
+	}
+	if filter.CampaignID != nil {
+		query = query.Where("campaign_id = ?", *filter.CampaignID)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.SentAfter != nil {
+		query = query.Where("sent_at >= ?", *filter.SentAfter)
+	}
+	if filter.SentBefore != nil {
+		query = query.Where("sent_at < ?", *filter.SentBefore)
+	}
+
+	op, dir := ">", "ASC"
+	if filter.Descending {
+		op, dir = "<", "DESC"
+	}
+	if filter.Cursor != "" {
+		createdAt, id, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where("(created_at, id) "+op+" (?, ?)", createdAt, id)
+	}
+
+	// fetch one extra row to know whether another page exists
+	var messages []model.Message
+	err := query.Order("created_at " + dir).
+		Order("id " + dir).
+		Limit(filter.Limit + 1).
+		Find(&messages).Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(messages) > filter.Limit {
+		messages = messages[:filter.Limit]
+		last := messages[len(messages)-1]
+		next = encodeCursor(last.CreatedAt, last.ID)
+	}
+	return messages, next, nil
+}
+
+// Position is where a page ends in a list ordered by time and then ID
+type Position struct {
+	At time.Time
+	ID uuid.UUID
+}
+
+// Cursor encodes the position as an opaque pagination cursor
+func (p Position) Cursor() string {
+	return encodeCursor(p.At, p.ID)
+}
+
+// ParsePosition decodes a cursor made by Position.Cursor
+func ParsePosition(cursor string) (Position, error) {
+	at, id, err := decodeCursor(cursor)
+	return Position{At: at, ID: id}, err
+}
+
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+
+	createdPart, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	return createdAt, id, nil
+}
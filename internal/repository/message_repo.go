@@ -1,10 +1,15 @@
 package repository
 
 import (
+	"context"
 	"insider-assessment/internal/model"
+	"insider-assessment/pkg/metrics"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MessageStatus string
@@ -16,10 +21,32 @@ const (
 )
 
 type MessageRepository interface {
-	GetPending(limit int) ([]model.Message, error)
+	ClaimPending(limit int) ([]model.Message, error)
+	ClaimByIDs(ids []uuid.UUID, expiredBefore time.Time) ([]model.Message, error)
+	ReleaseExpired(claimedBefore time.Time, limit int) ([]model.Message, error)
+	Cancel(id uuid.UUID, actor string) (*model.Message, error)
+	Retry(id uuid.UUID, actor string) (*model.Message, error)
+	Defer(id uuid.UUID, until time.Time) error
+	Suppress(id uuid.UUID) error
+	MarkDuplicate(id, originalID uuid.UUID) error
+	RecentlySent(hashes []string, since time.Time) (map[string]uuid.UUID, error)
+	InFlight(hashes []string) (map[string]uuid.UUID, error)
+	RetryFailed(filter RetryFilter, actor string) ([]model.Message, error)
 	UpdateStatus(id uuid.UUID, status model.MessageStatus) error
-	GetAllSent() ([]model.Message, error)
+	CompleteDelivery(id uuid.UUID, claimedAt *time.Time, status model.MessageStatus, latency time.Duration, remoteID string) error
+	List(filter MessageFilter) ([]model.Message, string, error)
+	GetByID(id uuid.UUID) (*model.Message, error)
+	History(id uuid.UUID) ([]model.StatusChange, error)
 	Create(msg *model.Message) error
+	CreateBatch(msgs []model.Message) error
+	WithContext(ctx context.Context) MessageRepository
+}
+
+// RetryFilter selects FAILED messages for a bulk retry. Zero values don't filter.
+type RetryFilter struct {
+	FailedAfter  *time.Time
+	FailedBefore *time.Time
+	CampaignID   *uuid.UUID
 }
 
 type messageRepository struct {
@@ -30,11 +57,68 @@ func NewMessageRepository(db *gorm.DB) MessageRepository {
 	return &messageRepository{DB: db}
 }
 
+// WithContext returns a repository whose queries run with ctx, so they join its trace
+func (r *messageRepository) WithContext(ctx context.Context) MessageRepository {
+	return &messageRepository{DB: r.DB.WithContext(ctx)}
+}
+
 func (r *messageRepository) Create(msg *model.Message) error {
-	return r.DB.Create(msg).Error
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(msg).Error; err != nil {
+			return err
+		}
+		return writeOutbox(tx, model.NewMessageEvent(model.EventMessageCreated, *msg))
+	})
+	if err != nil {
+		return err
+	}
+	metrics.MessagesEnqueued.WithLabelValues(metrics.ChannelSMS).Inc()
+	return nil
 }
 
+// CreateBatch inserts all messages in one transaction, 500 rows per statement
+func (r *messageRepository) CreateBatch(msgs []model.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&msgs, 500).Error; err != nil {
+			return err
+		}
+		return writeOutbox(tx, createdEvents(msgs)...)
+	})
+	if err != nil {
+		return err
+	}
+	metrics.MessagesEnqueued.WithLabelValues(metrics.ChannelSMS).Add(float64(len(msgs)))
+	return nil
+}
+
+// ActorWorker is recorded for status changes made by the background worker
+const ActorWorker = "worker"
+
+// ActorReaper is recorded when an expired claim is released
+const ActorReaper = "reaper"
+
+// UpdateStatus sets the worker's outcome of a message in PROCESSING and records it in the
+// status history
 func (r *messageRepository) UpdateStatus(id uuid.UUID, status model.MessageStatus) error {
+	return r.setStatus(id, nil, status, nil, "")
+}
+
+// CompleteDelivery records the outcome of a webhook call together with its latency and,
+// for accepted messages, the provider's ID. claimedAt is the claim the call was made under:
+// if the message was released, taken over or cancelled since, the outcome is dropped with
+// ErrConflict.
+func (r *messageRepository) CompleteDelivery(id uuid.UUID, claimedAt *time.Time, status model.MessageStatus, latency time.Duration, remoteID string) error {
+	ms := latency.Milliseconds()
+	return r.setStatus(id, claimedAt, status, &ms, remoteID)
+}
+
+// setStatus writes the status, its history entry and, for SENT and FAILED, the lifecycle
+// event to the outbox, all in one transaction. The message must still be in PROCESSING and,
+// when claimedAt is given, under that claim; ErrConflict is returned otherwise.
+func (r *messageRepository) setStatus(id uuid.UUID, claimedAt *time.Time, status model.MessageStatus, latencyMs *int64, remoteID string) error {
 	updates := map[string]interface{}{
 		"status": status,
 	}
@@ -42,21 +126,378 @@ func (r *messageRepository) UpdateStatus(id uuid.UUID, status model.MessageStatu
 		updates["sent_at"] = gorm.Expr("NOW()")
 	}
 
-	return r.DB.Model(&model.Message{}).Where("id = ?", id).Updates(updates).Error
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var current model.Message
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status", "to", "campaign_id", "claimed_at").
+			First(&current, "id = ?", id).Error
+		if err != nil {
+			return translateError(err)
+		}
+		if current.Status != model.StatusProcessing {
+			return ErrConflict
+		}
+		if claimedAt != nil && (current.ClaimedAt == nil || !current.ClaimedAt.Equal(*claimedAt)) {
+			return ErrConflict
+		}
+
+		if err := tx.Model(&model.Message{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		change := newStatusChange(id, current.Status, status, ActorWorker)
+		change.LatencyMs = latencyMs
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+
+		var eventType model.EventType
+		switch status {
+		case model.StatusSent:
+			eventType = model.EventMessageSent
+		case model.StatusFailed:
+			eventType = model.EventMessageFailed
+		default:
+			return nil
+		}
+		current.Status = status
+		event := model.NewMessageEvent(eventType, current)
+		event.Data.RemoteID = remoteID
+		return writeOutbox(tx, event)
+	})
+}
+
+// ClaimPending atomically moves the oldest pending messages to PROCESSING and returns them.
+// SKIP LOCKED lets concurrent workers claim disjoint batches. Campaign messages are held
+// back until their campaign is RUNNING and its scheduled start has passed, deferred messages
+// until their not_before time.
+func (r *messageRepository) ClaimPending(limit int) ([]model.Message, error) {
+	return r.claim(func(tx *gorm.DB) *gorm.DB {
+		return claimable(tx).
+			Order("created_at ASC").
+			Limit(limit)
+	})
 }
 
-func (r *messageRepository) GetPending(limit int) ([]model.Message, error) {
+// ClaimByIDs claims the given messages like ClaimPending, skipping those that are no longer
+// PENDING, not yet due or locked by a concurrent claim. It serves queues that pick the IDs.
+// Messages in PROCESSING whose claim is older than expiredBefore are taken over as well, with
+// a fresh claimed_at, so an entry reclaimed from a crashed consumer recovers its message; a
+// zero expiredBefore takes over nothing.
+func (r *messageRepository) ClaimByIDs(ids []uuid.UUID, expiredBefore time.Time) ([]model.Message, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
 	var messages []model.Message
-	result := r.DB.Where("status = ?", model.StatusPending).
-		Order("created_at ASC").
-		Limit(limit).
-		Find(&messages)
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimIn(tx, func(tx *gorm.DB) *gorm.DB {
+			return claimable(tx).Where("id IN ?", ids)
+		})
+		if err != nil || expiredBefore.IsZero() {
+			messages = claimed
+			return err
+		}
+
+		var taken []model.Message
+		result := tx.Model(&taken).
+			Clauses(clause.Returning{}).
+			Where("id IN (?)", tx.Model(&model.Message{}).
+				Select("id").
+				Where("id IN ?", ids).
+				Where("status = ?", model.StatusProcessing).
+				Where("claimed_at < ? OR (claimed_at IS NULL AND updated_at < ?)", expiredBefore, expiredBefore).
+				Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})).
+			Updates(map[string]interface{}{
+				"claimed_at": gorm.Expr("NOW()"),
+				"updated_at": gorm.Expr("NOW()"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		changes := make([]model.StatusChange, len(taken))
+		for i, msg := range taken {
+			changes[i] = newStatusChange(msg.ID, model.StatusProcessing, model.StatusProcessing, ActorWorker)
+			changes[i].Event = model.EventReclaimed
+		}
+		if len(changes) > 0 {
+			if err := tx.CreateInBatches(&changes, 500).Error; err != nil {
+				return err
+			}
+		}
+		messages = append(claimed, taken...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortByCreation(messages)
+	return messages, nil
+}
+
+// claimable selects the IDs of messages that may be sent now
+func claimable(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&model.Message{}).
+		Select("id").
+		Where("status = ?", model.StatusPending).
+		Where("not_before IS NULL OR not_before <= NOW()").
+		Where("campaign_id IS NULL OR campaign_id IN (?)", tx.Model(&model.Campaign{}).
+			Select("id").
+			Where("status = ? AND (scheduled_at IS NULL OR scheduled_at <= NOW())", model.CampaignRunning)).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+}
 
-	return messages, result.Error
+// claim moves the messages selected by pending to PROCESSING, oldest first
+func (r *messageRepository) claim(pending func(tx *gorm.DB) *gorm.DB) ([]model.Message, error) {
+	var messages []model.Message
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		messages, err = claimIn(tx, pending)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortByCreation(messages)
+	return messages, nil
 }
 
-func (r *messageRepository) GetAllSent() ([]model.Message, error) {
+// claimIn is claim within the transaction tx
+func claimIn(tx *gorm.DB, pending func(tx *gorm.DB) *gorm.DB) ([]model.Message, error) {
 	var messages []model.Message
-	result := r.DB.Where("status = ?", model.StatusSent).Find(&messages)
-	return messages, result.Error
+	result := tx.Model(&messages).
+		Clauses(clause.Returning{}).
+		Where("id IN (?)", pending(tx)).
+		Updates(map[string]interface{}{
+			"status":     model.StatusProcessing,
+			"claimed_at": gorm.Expr("NOW()"),
+			"updated_at": gorm.Expr("NOW()"),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return messages, recordChanges(tx, messages, model.StatusPending, ActorWorker)
+}
+
+// sortByCreation orders claimed messages oldest first; RETURNING does not keep the subquery order
+func sortByCreation(messages []model.Message) {
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+}
+
+// ReleaseExpired moves up to limit messages claimed before claimedBefore back to PENDING and
+// returns them. A worker that crashed, or failed to store an outcome, would otherwise leave
+// them in PROCESSING forever. Rows claimed before the lease existed count from updated_at.
+func (r *messageRepository) ReleaseExpired(claimedBefore time.Time, limit int) ([]model.Message, error) {
+	var released []model.Message
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&model.Message{}).
+			Select("id").
+			Where("status = ?", model.StatusProcessing).
+			Where("claimed_at < ? OR (claimed_at IS NULL AND updated_at < ?)", claimedBefore, claimedBefore).
+			Limit(limit).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+		result := tx.Model(&released).
+			Clauses(clause.Returning{}).
+			Where("id IN (?)", expired).
+			Updates(map[string]interface{}{
+				"status":     model.StatusPending,
+				"claimed_at": nil,
+			})
+		if result.Error != nil || len(released) == 0 {
+			return result.Error
+		}
+
+		changes := make([]model.StatusChange, len(released))
+		for i, msg := range released {
+			changes[i] = newStatusChange(msg.ID, model.StatusProcessing, model.StatusPending, ActorReaper)
+			changes[i].Event = model.EventReleased
+		}
+		return tx.CreateInBatches(&changes, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+// Cancel moves a PENDING message to CANCELLED. Messages already claimed or finished
+// return ErrConflict.
+func (r *messageRepository) Cancel(id uuid.UUID, actor string) (*model.Message, error) {
+	return r.transitionOne(id, model.StatusPending, map[string]interface{}{
+		"status": model.StatusCancelled,
+	}, actor)
+}
+
+// Retry moves a single FAILED message back to PENDING
+func (r *messageRepository) Retry(id uuid.UUID, actor string) (*model.Message, error) {
+	return r.transitionOne(id, model.StatusFailed, retryUpdates(actor), actor)
+}
+
+// Defer hands a claimed message back to the queue, to be claimed again no earlier than until
+func (r *messageRepository) Defer(id uuid.UUID, until time.Time) error {
+	_, err := r.transitionOne(id, model.StatusProcessing, map[string]interface{}{
+		"status":     model.StatusPending,
+		"not_before": until,
+	}, ActorWorker)
+	return err
+}
+
+// Suppress ends a claimed message whose recipient opted out
+func (r *messageRepository) Suppress(id uuid.UUID) error {
+	_, err := r.transitionOne(id, model.StatusProcessing, map[string]interface{}{
+		"status": model.StatusSuppressed,
+	}, ActorWorker)
+	return err
+}
+
+// MarkDuplicate ends a claimed message that repeats originalID
+func (r *messageRepository) MarkDuplicate(id, originalID uuid.UUID) error {
+	_, err := r.transitionOne(id, model.StatusProcessing, map[string]interface{}{
+		"status":       model.StatusDuplicate,
+		"duplicate_of": originalID,
+	}, ActorWorker)
+	return err
+}
+
+// RecentlySent maps each content hash to the first message with that hash sent since the given time
+func (r *messageRepository) RecentlySent(hashes []string, since time.Time) (map[string]uuid.UUID, error) {
+	return r.firstByHash(hashes, "sent_at ASC", "status = ? AND sent_at >= ?", model.StatusSent, since)
+}
+
+// InFlight maps each content hash to the oldest message with that hash that is claimed for sending
+func (r *messageRepository) InFlight(hashes []string) (map[string]uuid.UUID, error) {
+	return r.firstByHash(hashes, "created_at ASC, id ASC", "status = ?", model.StatusProcessing)
+}
+
+// firstByHash maps each content hash to the first message with that hash, in the given order,
+// among those matching the condition
+func (r *messageRepository) firstByHash(hashes []string, order string, cond string, args ...interface{}) (map[string]uuid.UUID, error) {
+	first := map[string]uuid.UUID{}
+	if len(hashes) == 0 {
+		return first, nil
+	}
+
+	var rows []struct {
+		ContentHash string
+		ID          uuid.UUID
+	}
+	err := r.DB.Model(&model.Message{}).
+		Select("DISTINCT ON (content_hash) content_hash, id").
+		Where("content_hash IN ?", hashes).
+		Where(cond, args...).
+		Order("content_hash, " + order).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		first[row.ContentHash] = row.ID
+	}
+	return first, nil
+}
+
+// RetryFailed moves every FAILED message matching the filter back to PENDING and returns the
+// reset messages with their ID, status, recipient and campaign.
+// The failure time is the row's updated_at.
+func (r *messageRepository) RetryFailed(filter RetryFilter, actor string) ([]model.Message, error) {
+	var retried []model.Message
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&retried).Where("status = ?", model.StatusFailed)
+		if filter.FailedAfter != nil {
+			query = query.Where("updated_at >= ?", *filter.FailedAfter)
+		}
+		if filter.FailedBefore != nil {
+			query = query.Where("updated_at < ?", *filter.FailedBefore)
+		}
+		if filter.CampaignID != nil {
+			query = query.Where("campaign_id = ?", *filter.CampaignID)
+		}
+
+		result := query.
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "status"}, {Name: "to"}, {Name: "campaign_id"}}}).
+			Updates(retryUpdates(actor))
+		if result.Error != nil {
+			return result.Error
+		}
+		return recordChanges(tx, retried, model.StatusFailed, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return retried, nil
+}
+
+func retryUpdates(actor string) map[string]interface{} {
+	return map[string]interface{}{
+		"status":      model.StatusPending,
+		"retry_count": gorm.Expr("retry_count + 1"),
+		"retried_by":  actor,
+		"retried_at":  gorm.Expr("NOW()"),
+	}
+}
+
+// transitionOne applies updates to a message that is currently in status from,
+// returning ErrConflict when it is in any other status.
+func (r *messageRepository) transitionOne(id uuid.UUID, from model.MessageStatus, updates map[string]interface{}, actor string) (*model.Message, error) {
+	var msg model.Message
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&msg).
+			Clauses(clause.Returning{}).
+			Where("id = ? AND status = ?", id, from).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := tx.Select("id").First(&model.Message{}, "id = ?", id).Error; err != nil {
+				return translateError(err)
+			}
+			return ErrConflict
+		}
+		return recordChanges(tx, []model.Message{msg}, from, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// recordChanges appends one status history row per message, using each message's new status
+func recordChanges(tx *gorm.DB, msgs []model.Message, from model.MessageStatus, actor string) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	changes := make([]model.StatusChange, len(msgs))
+	for i, msg := range msgs {
+		changes[i] = newStatusChange(msg.ID, from, msg.Status, actor)
+	}
+	return tx.CreateInBatches(&changes, 500).Error
+}
+
+func newStatusChange(id uuid.UUID, from, to model.MessageStatus, actor string) model.StatusChange {
+	return model.StatusChange{
+		MessageID:  id,
+		Event:      model.TransitionEvent(from, to),
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor,
+	}
+}
+
+func (r *messageRepository) GetByID(id uuid.UUID) (*model.Message, error) {
+	var msg model.Message
+	if err := r.DB.First(&msg, "id = ?", id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &msg, nil
+}
+
+// History returns the recorded status changes of a message, oldest first
+func (r *messageRepository) History(id uuid.UUID) ([]model.StatusChange, error) {
+	var changes []model.StatusChange
+	result := r.DB.Where("message_id = ?", id).Order("created_at ASC, id ASC").Find(&changes)
+	return changes, result.Error
 }
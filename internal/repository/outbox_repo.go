@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"encoding/json"
+	"insider-assessment/internal/model"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxRepository hands stored lifecycle events to the relay
+type OutboxRepository interface {
+	Lease(limit, maxAttempts int, lease time.Duration) ([]model.OutboxEvent, error)
+	MarkDelivered(id uint64, leasedUntil time.Time, deliveredTo []string) error
+	MarkFailed(id uint64, leasedUntil time.Time, deliveredTo []string, lastError string, nextAttemptAt time.Time) error
+	Purge(before time.Time, maxAttempts int) (int64, error)
+}
+
+type outboxRepository struct {
+	DB *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{DB: db}
+}
+
+// Lease takes up to limit due events in publish order and returns them with the attempt
+// counted. Their next attempt is pushed out by lease, so replicas don't publish the same event
+// at the same time while the caller delivers it outside of any transaction, and an event whose
+// relay crashed is picked up again once the lease ran out.
+func (r *outboxRepository) Lease(limit, maxAttempts int, lease time.Duration) ([]model.OutboxEvent, error) {
+	var leased []model.OutboxEvent
+	due := r.DB.Model(&model.OutboxEvent{}).
+		Select("id").
+		Where("delivered_at IS NULL AND next_attempt_at <= NOW() AND attempts < ?", maxAttempts).
+		Order("id ASC").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	err := r.DB.Model(&leased).
+		Clauses(clause.Returning{}).
+		Where("id IN (?)", due).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": time.Now().Add(lease),
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the subquery order
+	sort.Slice(leased, func(i, j int) bool { return leased[i].ID < leased[j].ID })
+	return leased, nil
+}
+
+// MarkDelivered records that the event reached every target. leasedUntil is the
+// next_attempt_at Lease returned; if the lease ran out and another relay took the event
+// over, nothing is written and ErrConflict is returned.
+func (r *outboxRepository) MarkDelivered(id uint64, leasedUntil time.Time, deliveredTo []string) error {
+	return r.updateLeased(id, leasedUntil, map[string]interface{}{
+		"delivered_at": gorm.Expr("NOW()"),
+		"delivered_to": marshalTargets(deliveredTo),
+		"last_error":   "",
+	})
+}
+
+// MarkFailed records the targets the event reached so far and when to try the others again,
+// under the same lease condition as MarkDelivered
+func (r *outboxRepository) MarkFailed(id uint64, leasedUntil time.Time, deliveredTo []string, lastError string, nextAttemptAt time.Time) error {
+	return r.updateLeased(id, leasedUntil, map[string]interface{}{
+		"delivered_to":    marshalTargets(deliveredTo),
+		"last_error":      lastError,
+		"next_attempt_at": nextAttemptAt,
+	})
+}
+
+// updateLeased applies the updates if the event is still undelivered and under the lease
+func (r *outboxRepository) updateLeased(id uint64, leasedUntil time.Time, updates map[string]interface{}) error {
+	result := r.DB.Model(&model.OutboxEvent{}).
+		Where("id = ? AND delivered_at IS NULL AND next_attempt_at = ?", id, leasedUntil).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// Purge deletes events delivered before the given time, and events that used up maxAttempts
+// and were last tried before it
+func (r *outboxRepository) Purge(before time.Time, maxAttempts int) (int64, error) {
+	result := r.DB.
+		Where("delivered_at < ? OR (delivered_at IS NULL AND attempts >= ? AND next_attempt_at < ?)", before, maxAttempts, before).
+		Delete(&model.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// marshalTargets encodes delivered targets for the jsonb column, which a map update doesn't
+// run through the field's serializer
+func marshalTargets(targets []string) string {
+	if targets == nil {
+		targets = []string{}
+	}
+	encoded, _ := json.Marshal(targets)
+	return string(encoded)
+}
+
+// writeOutbox stores events in the caller's transaction
+func writeOutbox(tx *gorm.DB, events ...model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	rows := make([]model.OutboxEvent, len(events))
+	for i, event := range events {
+		rows[i] = model.NewOutboxEvent(event)
+	}
+	return tx.CreateInBatches(&rows, 500).Error
+}
+
+// createdEvents describes newly inserted messages
+func createdEvents(msgs []model.Message) []model.Event {
+	events := make([]model.Event, len(msgs))
+	for i, msg := range msgs {
+		events[i] = model.NewMessageEvent(model.EventMessageCreated, msg)
+	}
+	return events
+}
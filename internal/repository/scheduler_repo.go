@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"errors"
+	"insider-assessment/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SchedulerStateRepository interface {
+	Get() (*model.SchedulerState, error)
+	SetPaused(paused bool, actor string) (*model.SchedulerState, error)
+}
+
+type schedulerStateRepository struct {
+	DB *gorm.DB
+}
+
+func NewSchedulerStateRepository(db *gorm.DB) SchedulerStateRepository {
+	return &schedulerStateRepository{DB: db}
+}
+
+// Get returns the desired state. Until someone changes it the scheduler is running.
+func (r *schedulerStateRepository) Get() (*model.SchedulerState, error) {
+	var state model.SchedulerState
+	err := r.DB.First(&state, model.SchedulerStateID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.SchedulerState{ID: model.SchedulerStateID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SetPaused flips the desired state. It returns ErrConflict when the state already is the requested one,
+// which a conditional UPDATE decides atomically even when replicas race.
+func (r *schedulerStateRepository) SetPaused(paused bool, actor string) (*model.SchedulerState, error) {
+	seed := model.SchedulerState{ID: model.SchedulerStateID}
+	if err := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&seed).Error; err != nil {
+		return nil, err
+	}
+
+	result := r.DB.Model(&model.SchedulerState{}).
+		Where("id = ? AND paused <> ?", model.SchedulerStateID, paused).
+		Updates(map[string]interface{}{
+			"paused":     paused,
+			"updated_by": actor,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrConflict
+	}
+	return r.Get()
+}
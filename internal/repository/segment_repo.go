@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"insider-assessment/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SegmentRepository interface {
+	Create(segment *model.Segment) error
+	GetByID(id uuid.UUID) (*model.Segment, error)
+	Update(segment *model.Segment) error
+	Delete(id uuid.UUID) error
+	List() ([]model.Segment, error)
+}
+
+type segmentRepository struct {
+	DB *gorm.DB
+}
+
+func NewSegmentRepository(db *gorm.DB) SegmentRepository {
+	return &segmentRepository{DB: db}
+}
+
+func (r *segmentRepository) Create(segment *model.Segment) error {
+	return r.DB.Create(segment).Error
+}
+
+func (r *segmentRepository) GetByID(id uuid.UUID) (*model.Segment, error) {
+	var segment model.Segment
+	if err := r.DB.First(&segment, "id = ?", id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &segment, nil
+}
+
+// Update replaces the name and conditions of the segment
+func (r *segmentRepository) Update(segment *model.Segment) error {
+	result := r.DB.Model(segment).
+		Clauses(clause.Returning{}).
+		Select("name", "conditions", "updated_at").
+		Updates(segment)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *segmentRepository) Delete(id uuid.UUID) error {
+	result := r.DB.Delete(&model.Segment{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns every segment, oldest first
+func (r *segmentRepository) List() ([]model.Segment, error) {
+	var segments []model.Segment
+	err := r.DB.Order("created_at ASC").Find(&segments).Error
+	return segments, err
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"insider-assessment/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stats is a point-in-time summary of the queue and recent delivery activity
+type Stats struct {
+	Counts           map[model.MessageStatus]int64 `json:"counts"`
+	OldestPendingAge float64                       `json:"oldest_pending_age_seconds"`
+	SentLastMinute   int64                         `json:"sent_last_minute"`
+	SentLastHour     int64                         `json:"sent_last_hour"`
+	FailedLastHour   int64                         `json:"failed_last_hour"`
+	FailureRate      float64                       `json:"failure_rate"` // failed / (sent + failed) over the last hour
+	LatencyP50Ms     float64                       `json:"webhook_latency_p50_ms"`
+	LatencyP95Ms     float64                       `json:"webhook_latency_p95_ms"`
+	GeneratedAt      time.Time                     `json:"generated_at"`
+}
+
+type StatsRepository interface {
+	Collect() (*Stats, error)
+	CountPending() (int64, error)
+}
+
+type statsRepository struct {
+	DB *gorm.DB
+}
+
+func NewStatsRepository(db *gorm.DB) StatsRepository {
+	return &statsRepository{DB: db}
+}
+
+// Collect aggregates queue counts from messages and throughput/latency from the status history
+func (r *statsRepository) Collect() (*Stats, error) {
+	stats := &Stats{
+		Counts:      make(map[model.MessageStatus]int64),
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	var counts []struct {
+		Status model.MessageStatus
+		Count  int64
+	}
+	err := r.DB.Model(&model.Message{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range counts {
+		stats.Counts[row.Status] = row.Count
+	}
+
+	var oldest struct {
+		Age *float64
+	}
+	err = r.DB.Model(&model.Message{}).
+		Select("EXTRACT(EPOCH FROM NOW() - MIN(created_at)) AS age").
+		Where("status = ?", model.StatusPending).
+		Scan(&oldest).Error
+	if err != nil {
+		return nil, err
+	}
+	if oldest.Age != nil {
+		stats.OldestPendingAge = *oldest.Age
+	}
+
+	var window struct {
+		SentLastMinute int64
+		SentLastHour   int64
+		FailedLastHour int64
+		P50            *float64
+		P95            *float64
+	}
+	err = r.DB.Model(&model.StatusChange{}).
+		Select(`COUNT(*) FILTER (WHERE to_status = ? AND created_at >= NOW() - INTERVAL '1 minute') AS sent_last_minute,
+			COUNT(*) FILTER (WHERE to_status = ?) AS sent_last_hour,
+			COUNT(*) FILTER (WHERE to_status = ?) AS failed_last_hour,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY latency_ms) AS p50,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY latency_ms) AS p95`,
+			model.StatusSent, model.StatusSent, model.StatusFailed).
+		Where("created_at >= NOW() - INTERVAL '1 hour'").
+		Where("to_status IN ?", []model.MessageStatus{model.StatusSent, model.StatusFailed}).
+		Scan(&window).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats.SentLastMinute = window.SentLastMinute
+	stats.SentLastHour = window.SentLastHour
+	stats.FailedLastHour = window.FailedLastHour
+	if total := window.SentLastHour + window.FailedLastHour; total > 0 {
+		stats.FailureRate = float64(window.FailedLastHour) / float64(total)
+	}
+	if window.P50 != nil {
+		stats.LatencyP50Ms = *window.P50
+	}
+	if window.P95 != nil {
+		stats.LatencyP95Ms = *window.P95
+	}
+
+	return stats, nil
+}
+
+// CountPending returns the current queue depth
+func (r *statsRepository) CountPending() (int64, error) {
+	var count int64
+	err := r.DB.Model(&model.Message{}).Where("status = ?", model.StatusPending).Count(&count).Error
+	return count, err
+}
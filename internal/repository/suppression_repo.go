@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"insider-assessment/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SuppressionRepository interface {
+	Add(s *model.Suppression) error
+	Remove(phone string) error
+	List(cursor string, limit int) ([]model.Suppression, string, error)
+	Suppressed(phones []string) (map[string]bool, error)
+}
+
+type suppressionRepository struct {
+	DB *gorm.DB
+}
+
+func NewSuppressionRepository(db *gorm.DB) SuppressionRepository {
+	return &suppressionRepository{DB: db}
+}
+
+// Add stores the suppression, returning ErrConflict when the number is already suppressed
+func (r *suppressionRepository) Add(s *model.Suppression) error {
+	result := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(s)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *suppressionRepository) Remove(phone string) error {
+	result := r.DB.Delete(&model.Suppression{}, "phone = ?", phone)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List pages through the suppressions ordered by number. The cursor is the last number of
+// the previous page; an empty next cursor means there are no more pages.
+func (r *suppressionRepository) List(cursor string, limit int) ([]model.Suppression, string, error) {
+	query := r.DB.Order("phone ASC").Limit(limit + 1)
+	if cursor != "" {
+		query = query.Where("phone > ?", cursor)
+	}
+
+	var page []model.Suppression
+	if err := query.Find(&page).Error; err != nil {
+		return nil, "", err
+	}
+	if len(page) <= limit {
+		return page, "", nil
+	}
+	page = page[:limit]
+	return page, page[limit-1].Phone, nil
+}
+
+// Suppressed reports which of the normalized numbers are suppressed
+func (r *suppressionRepository) Suppressed(phones []string) (map[string]bool, error) {
+	suppressed := map[string]bool{}
+	if len(phones) == 0 {
+		return suppressed, nil
+	}
+
+	var found []string
+	if err := r.DB.Model(&model.Suppression{}).Where("phone IN ?", phones).Pluck("phone", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, phone := range found {
+		suppressed[phone] = true
+	}
+	return suppressed, nil
+}
@@ -2,8 +2,10 @@ package router
 
 import (
 	"insider-assessment/internal/handler"
+	"insider-assessment/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -13,13 +15,55 @@ func InitRoutes(r *gin.Engine, h *handler.Handler) {
 	// swagger Route
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// prometheus scrape endpoint
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	api := r.Group("/")
+	api.Use(tracing.Middleware())
 	{
 		api.POST("/start", h.StartScheduler)
 		api.POST("/stop", h.StopScheduler)
+		api.GET("/scheduler", h.GetScheduler)
+		api.PATCH("/scheduler", h.UpdateScheduler)
+		api.POST("/scheduler/run", h.RunScheduler)
 		api.GET("/sent-messages", h.GetSentMessages)
 		api.POST("/messages", h.AddMessage) // helper for testing
+		api.GET("/messages", h.ListMessages)
+		api.GET("/messages/stream", h.StreamMessages)
+		api.GET("/messages/:id", h.GetMessage)
+		api.POST("/messages/:id/cancel", h.CancelMessage)
+		api.POST("/messages/:id/retry", h.RetryMessage)
+		api.POST("/messages/retry", h.RetryMessages)
 		api.GET("/health", h.HealthCheck)
+		api.GET("/stats", h.GetStats)
 		api.GET("/messages/cache", h.GetAllCachedMessages)
+		api.GET("/messages/cache/:remote_id", h.GetCachedByRemoteID)
+		api.GET("/messages/:id/cache", h.GetCachedByMessageID)
+		api.POST("/imports", h.ImportMessages)
+		api.GET("/imports/:id", h.GetImport)
+		api.GET("/imports/:id/errors", h.GetImportErrors)
+		api.POST("/campaigns", h.CreateCampaign)
+		api.GET("/campaigns/:id", h.GetCampaign)
+		api.POST("/campaigns/:id/launch", h.LaunchCampaign)
+		api.POST("/campaigns/:id/pause", h.PauseCampaign)
+		api.POST("/campaigns/:id/cancel", h.CancelCampaign)
+		api.POST("/event-subscriptions", h.CreateSubscription)
+		api.GET("/event-subscriptions", h.ListSubscriptions)
+		api.DELETE("/event-subscriptions/:id", h.DeleteSubscription)
+		api.POST("/suppressions", h.CreateSuppression)
+		api.GET("/suppressions", h.ListSuppressions)
+		api.DELETE("/suppressions/:phone", h.DeleteSuppression)
+		api.POST("/inbound", h.ReceiveInbound)
+		api.GET("/conversations/:phone", h.GetConversation)
+		api.POST("/contacts", h.CreateContact)
+		api.GET("/contacts", h.ListContacts)
+		api.GET("/contacts/:id", h.GetContact)
+		api.PUT("/contacts/:id", h.UpdateContact)
+		api.DELETE("/contacts/:id", h.DeleteContact)
+		api.POST("/segments", h.CreateSegment)
+		api.GET("/segments", h.ListSegments)
+		api.GET("/segments/:id", h.GetSegment)
+		api.PUT("/segments/:id", h.UpdateSegment)
+		api.DELETE("/segments/:id", h.DeleteSegment)
 	}
 }
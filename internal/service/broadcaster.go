@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"insider-assessment/internal/model"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// StatusUpdate is one status transition as pushed to live listeners
+type StatusUpdate struct {
+	ID         uint64              `json:"id"` // increases with every update, across restarts too
+	MessageID  uuid.UUID           `json:"message_id"`
+	To         string              `json:"to"`
+	CampaignID *uuid.UUID          `json:"campaign_id,omitempty"`
+	From       model.MessageStatus `json:"from"`
+	Status     model.MessageStatus `json:"status"`
+	At         time.Time           `json:"at"`
+}
+
+// UpdateFilter selects the updates a listener receives; empty fields match everything
+type UpdateFilter struct {
+	To         string
+	CampaignID *uuid.UUID
+	Status     model.MessageStatus
+}
+
+func (f UpdateFilter) Match(u StatusUpdate) bool {
+	if f.To != "" && f.To != u.To {
+		return false
+	}
+	if f.CampaignID != nil && (u.CampaignID == nil || *u.CampaignID != *f.CampaignID) {
+		return false
+	}
+	return f.Status == "" || f.Status == u.Status
+}
+
+// listenerBuffer is how many updates a listener may fall behind before it is dropped
+const listenerBuffer = 256
+
+// Broadcaster fans status transitions out to live listeners and keeps the most recent ones
+// so a listener that reconnects can resume where it stopped. Without Connect it is
+// in-process and listeners only see this replica's transitions; connected, every update goes
+// through a Redis Pub/Sub channel and reaches the listeners of every replica. Update IDs are
+// assigned by the receiving replica, so a listener resumes on the replica it was connected to.
+type Broadcaster struct {
+	mu        sync.Mutex
+	next      uint64
+	history   []StatusUpdate
+	size      int
+	listeners map[*Listener]struct{}
+
+	redis   *redis.Client
+	channel string
+	pubsub  *redis.PubSub
+	done    chan struct{}
+}
+
+// Listener receives matching updates on C. C is closed when the listener falls too far
+// behind; the client then reconnects and resumes from the last update it saw.
+type Listener struct {
+	C      <-chan StatusUpdate
+	c      chan StatusUpdate
+	filter UpdateFilter
+	b      *Broadcaster
+}
+
+// NewBroadcaster keeps the last historySize updates for resuming listeners. IDs start at
+// the current time in microseconds, so they keep increasing when the process restarts.
+func NewBroadcaster(historySize int) *Broadcaster {
+	return &Broadcaster{
+		next:      uint64(time.Now().UnixMicro()),
+		size:      historySize,
+		listeners: map[*Listener]struct{}{},
+	}
+}
+
+// Connect relays updates through the Redis Pub/Sub channel so every replica's listeners
+// receive them, until Close is called
+func (b *Broadcaster) Connect(ctx context.Context, rdb *redis.Client, channel string) error {
+	pubsub := rdb.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+
+	b.mu.Lock()
+	b.redis, b.channel, b.pubsub = rdb, channel, pubsub
+	b.done = make(chan struct{})
+	b.mu.Unlock()
+
+	go func() {
+		defer close(b.done)
+		for msg := range pubsub.Channel() {
+			var u StatusUpdate
+			if err := json.Unmarshal([]byte(msg.Payload), &u); err != nil {
+				slog.Warn("dropping malformed status update", "error", err)
+				continue
+			}
+			b.deliver(u)
+		}
+	}()
+	return nil
+}
+
+// Close stops relaying through Redis
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	pubsub, done := b.pubsub, b.done
+	b.redis, b.pubsub = nil, nil
+	b.mu.Unlock()
+
+	if pubsub == nil {
+		return
+	}
+	pubsub.Close()
+	<-done
+}
+
+// Publish hands the updates to every matching listener without blocking, through Redis when
+// connected. If Redis can't be reached they still go to this replica's listeners. A nil
+// broadcaster does nothing.
+func (b *Broadcaster) Publish(updates ...StatusUpdate) {
+	if b == nil || len(updates) == 0 {
+		return
+	}
+	b.mu.Lock()
+	rdb, channel := b.redis, b.channel
+	b.mu.Unlock()
+
+	if rdb == nil {
+		b.deliver(updates...)
+		return
+	}
+	now := time.Now().UTC()
+	ctx := context.Background()
+	_, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, u := range updates {
+			if u.At.IsZero() {
+				u.At = now
+			}
+			payload, _ := json.Marshal(u)
+			pipe.Publish(ctx, channel, payload)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Warn("failed to relay status updates, only local listeners get them", "count", len(updates), "error", err)
+		b.deliver(updates...)
+	}
+}
+
+// deliver assigns the updates their IDs and hands them to the matching listeners
+func (b *Broadcaster) deliver(updates ...StatusUpdate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, u := range updates {
+		b.next++
+		u.ID = b.next
+		if u.At.IsZero() {
+			u.At = time.Now().UTC()
+		}
+		if b.size > 0 {
+			if len(b.history) == b.size {
+				b.history = append(b.history[1:], u)
+			} else {
+				b.history = append(b.history, u)
+			}
+		}
+
+		for l := range b.listeners {
+			if !l.filter.Match(u) {
+				continue
+			}
+			select {
+			case l.c <- u:
+			default:
+				b.drop(l)
+			}
+		}
+	}
+}
+
+// Subscribe registers a listener and returns the kept updates after lastID that match the
+// filter, which it must send before reading C. lastID 0 starts with live updates only.
+func (b *Broadcaster) Subscribe(filter UpdateFilter, lastID uint64) (*Listener, []StatusUpdate) {
+	c := make(chan StatusUpdate, listenerBuffer)
+	l := &Listener{C: c, c: c, filter: filter, b: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []StatusUpdate
+	if lastID > 0 {
+		for _, u := range b.history {
+			if u.ID > lastID && filter.Match(u) {
+				replay = append(replay, u)
+			}
+		}
+	}
+	b.listeners[l] = struct{}{}
+	return l, replay
+}
+
+// Close unregisters the listener
+func (l *Listener) Close() {
+	l.b.mu.Lock()
+	defer l.b.mu.Unlock()
+	l.b.drop(l)
+}
+
+// Listeners returns how many listeners are connected
+func (b *Broadcaster) Listeners() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.listeners)
+}
+
+// drop removes a listener; the caller holds mu
+func (b *Broadcaster) drop(l *Listener) {
+	if _, ok := b.listeners[l]; ok {
+		delete(b.listeners, l)
+		close(l.c)
+	}
+}
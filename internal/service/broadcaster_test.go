@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"context"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/service"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcaster_FiltersAndResumes(t *testing.T) {
+	b := service.NewBroadcaster(10)
+	campaign := uuid.New()
+
+	b.Publish(
+		service.StatusUpdate{MessageID: uuid.New(), To: "+905551112233", From: model.StatusPending, Status: model.StatusProcessing},
+		service.StatusUpdate{MessageID: uuid.New(), To: "+905551112233", CampaignID: &campaign, From: model.StatusProcessing, Status: model.StatusSent},
+	)
+	first, replay := b.Subscribe(service.UpdateFilter{}, 1)
+	require.Len(t, replay, 2)
+	assert.Less(t, replay[0].ID, replay[1].ID)
+	first.Close()
+
+	// resuming after the first update replays only the second
+	l, replay := b.Subscribe(service.UpdateFilter{CampaignID: &campaign}, replay[0].ID)
+	defer l.Close()
+	require.Len(t, replay, 1)
+	assert.Equal(t, model.StatusSent, replay[0].Status)
+
+	b.Publish(
+		service.StatusUpdate{MessageID: uuid.New(), To: "+905551112233", Status: model.StatusFailed}, // other campaign
+		service.StatusUpdate{MessageID: uuid.New(), CampaignID: &campaign, Status: model.StatusFailed},
+	)
+	got := <-l.C
+	assert.Equal(t, campaign, *got.CampaignID)
+	assert.Greater(t, got.ID, replay[0].ID)
+	assert.Empty(t, l.C)
+}
+
+func TestBroadcaster_DropsSlowListener(t *testing.T) {
+	b := service.NewBroadcaster(0)
+	l, _ := b.Subscribe(service.UpdateFilter{}, 0)
+
+	for i := 0; i < 1000; i++ {
+		b.Publish(service.StatusUpdate{MessageID: uuid.New(), Status: model.StatusSent})
+	}
+	assert.Equal(t, 0, b.Listeners())
+
+	received := 0
+	for range l.C { // closed once it fell behind
+		received++
+	}
+	assert.Less(t, received, 1000)
+	l.Close() // closing a dropped listener is harmless
+}
+
+func TestBroadcaster_FansOutAcrossReplicas(t *testing.T) {
+	rdb := newTestRedis(t)
+	leader, follower := service.NewBroadcaster(10), service.NewBroadcaster(10)
+	require.NoError(t, leader.Connect(context.Background(), rdb, "updates"))
+	defer leader.Close()
+	require.NoError(t, follower.Connect(context.Background(), rdb, "updates"))
+	defer follower.Close()
+
+	onLeader, _ := leader.Subscribe(service.UpdateFilter{}, 0)
+	defer onLeader.Close()
+	onFollower, _ := follower.Subscribe(service.UpdateFilter{Status: model.StatusSent}, 0)
+	defer onFollower.Close()
+
+	sent := service.StatusUpdate{MessageID: uuid.New(), To: "+905551112233", From: model.StatusProcessing, Status: model.StatusSent}
+	leader.Publish(service.StatusUpdate{MessageID: uuid.New(), Status: model.StatusProcessing}, sent)
+
+	for _, l := range []*service.Listener{onLeader, onLeader, onFollower} {
+		select {
+		case got := <-l.C:
+			assert.NotZero(t, got.ID)
+			assert.False(t, got.At.IsZero())
+		case <-time.After(time.Second):
+			t.Fatal("update not received")
+		}
+	}
+	// the follower keeps the updates for its own resuming listeners
+	probe, kept := follower.Subscribe(service.UpdateFilter{}, 1)
+	probe.Close()
+	require.Len(t, kept, 2)
+	assert.Equal(t, sent.MessageID, kept[1].MessageID)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CampaignService manages the campaign lifecycle and its fan-out into messages
+type CampaignService struct {
+	Repo repository.CampaignRepository
+}
+
+func NewCampaignService(repo repository.CampaignRepository) *CampaignService {
+	return &CampaignService{Repo: repo}
+}
+
+// CampaignProgress is computed from the campaign's messages
+type CampaignProgress struct {
+	Total      int64 `json:"total"`
+	Pending    int64 `json:"pending"` // includes messages currently being sent
+	Sent       int64 `json:"sent"`
+	Failed     int64 `json:"failed"`
+	Cancelled  int64 `json:"cancelled"`
+	Suppressed int64 `json:"suppressed"`
+	Duplicate  int64 `json:"duplicate"`
+}
+
+type CampaignView struct {
+	model.Campaign
+	Progress CampaignProgress `json:"progress"`
+}
+
+// ValidationError lists every invalid audience entry so callers can fix them in one go
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid campaign: " + strings.Join(e.Problems, "; ")
+}
+
+// Create validates the template against every recipient and stores the campaign as DRAFT
+func (s *CampaignService) Create(campaign *model.Campaign) error {
+	if campaign.ScheduledAt != nil && campaign.ScheduledAt.Before(time.Now()) {
+		return &ValidationError{Problems: []string{"scheduled_at is in the past"}}
+	}
+	if _, err := s.buildMessages(campaign); err != nil {
+		return err
+	}
+
+	campaign.Status = model.CampaignDraft
+	return s.Repo.Create(campaign)
+}
+
+// Launch fans a DRAFT campaign out into pending messages, or resumes a PAUSED one.
+// Messages stay held until ScheduledAt when the campaign is scheduled for later.
+func (s *CampaignService) Launch(id uuid.UUID) (*model.Campaign, error) {
+	campaign, err := s.Repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch campaign.Status {
+	case model.CampaignPaused:
+		err = s.Repo.Transition(id, []model.CampaignStatus{model.CampaignPaused}, model.CampaignRunning)
+	case model.CampaignDraft:
+		var messages []model.Message
+		messages, err = s.buildMessages(campaign)
+		if err == nil {
+			err = s.Repo.Launch(id, messages)
+		}
+		if err == nil {
+			slog.Info("campaign launched", "campaign_id", id, "messages", len(messages))
+		}
+	default:
+		err = repository.ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Repo.GetByID(id)
+}
+
+// Pause holds the remaining pending messages of a RUNNING campaign
+func (s *CampaignService) Pause(id uuid.UUID) (*model.Campaign, error) {
+	if err := s.Repo.Transition(id, []model.CampaignStatus{model.CampaignRunning}, model.CampaignPaused); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetByID(id)
+}
+
+// Cancel stops the campaign for good and cancels its pending messages
+func (s *CampaignService) Cancel(id uuid.UUID) (*model.Campaign, error) {
+	if err := s.Repo.Cancel(id); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetByID(id)
+}
+
+// Get returns the campaign together with its delivery progress
+func (s *CampaignService) Get(id uuid.UUID) (*CampaignView, error) {
+	campaign, err := s.Repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.Repo.CountMessages(id)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := CampaignProgress{
+		Pending:    counts[model.StatusPending] + counts[model.StatusProcessing],
+		Sent:       counts[model.StatusSent],
+		Failed:     counts[model.StatusFailed],
+		Cancelled:  counts[model.StatusCancelled],
+		Suppressed: counts[model.StatusSuppressed],
+		Duplicate:  counts[model.StatusDuplicate],
+	}
+	for _, n := range counts {
+		progress.Total += n
+	}
+
+	return &CampaignView{Campaign: *campaign, Progress: progress}, nil
+}
+
+func (s *CampaignService) buildMessages(campaign *model.Campaign) ([]model.Message, error) {
+	var problems []string
+	if strings.TrimSpace(campaign.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	if strings.TrimSpace(campaign.Template) == "" {
+		problems = append(problems, "template is required")
+	}
+	if len(campaign.Audience) == 0 {
+		problems = append(problems, "audience is empty")
+	}
+	if err := checkCategory(campaign.Category); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	messages := make([]model.Message, 0, len(campaign.Audience))
+	for i, recipient := range campaign.Audience {
+		msg, err := buildCampaignMessage(campaign, recipient)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("audience[%d]: %s", i, err))
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return messages, nil
+}
+
+func buildCampaignMessage(campaign *model.Campaign, recipient model.CampaignRecipient) (model.Message, error) {
+	content, err := RenderTemplate(campaign.Template, recipient.Vars)
+	if err != nil {
+		return model.Message{}, err
+	}
+
+	msg, err := buildMessage(recipient.To, content)
+	if err != nil {
+		return model.Message{}, err
+	}
+	if err := checkTimezone(recipient.Timezone); err != nil {
+		return model.Message{}, err
+	}
+	msg.CampaignID = &campaign.ID
+	msg.Category = model.NormalizeCategory(campaign.Category)
+	msg.Timezone = recipient.Timezone
+	return msg, nil
+}
+
+// IsValidationError reports whether err was caused by invalid input
+func IsValidationError(err error) bool {
+	var vErr *ValidationError
+	return errors.As(err, &vErr)
+}
@@ -0,0 +1,125 @@
+package service_test
+
+import (
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"insider-assessment/internal/service"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCampaignRepository is a mock implementation of repository.CampaignRepository
+type MockCampaignRepository struct {
+	mock.Mock
+}
+
+func (m *MockCampaignRepository) Create(campaign *model.Campaign) error {
+	args := m.Called(campaign)
+	return args.Error(0)
+}
+
+func (m *MockCampaignRepository) GetByID(id uuid.UUID) (*model.Campaign, error) {
+	args := m.Called(id)
+	if c, ok := args.Get(0).(*model.Campaign); ok {
+		return c, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCampaignRepository) Launch(id uuid.UUID, messages []model.Message) error {
+	args := m.Called(id, messages)
+	return args.Error(0)
+}
+
+func (m *MockCampaignRepository) Transition(id uuid.UUID, from []model.CampaignStatus, to model.CampaignStatus) error {
+	args := m.Called(id, from, to)
+	return args.Error(0)
+}
+
+func (m *MockCampaignRepository) Cancel(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockCampaignRepository) CountMessages(id uuid.UUID) (map[model.MessageStatus]int64, error) {
+	args := m.Called(id)
+	return args.Get(0).(map[model.MessageStatus]int64), args.Error(1)
+}
+
+func TestCampaignService_CreateRejectsInvalidAudience(t *testing.T) {
+	repo := new(MockCampaignRepository)
+	svc := service.NewCampaignService(repo)
+
+	err := svc.Create(&model.Campaign{
+		Name:     "Spring sale",
+		Template: "Hi {{name}}",
+		Audience: []model.CampaignRecipient{
+			{To: "+905551112233", Vars: map[string]string{"name": "Ada"}},
+			{To: "+905554445566"},
+		},
+	})
+
+	assert.True(t, service.IsValidationError(err))
+	assert.Contains(t, err.Error(), "audience[1]: missing template variables: name")
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCampaignService_LaunchFansOutMessages(t *testing.T) {
+	repo := new(MockCampaignRepository)
+	svc := service.NewCampaignService(repo)
+
+	campaign := &model.Campaign{
+		ID:       uuid.New(),
+		Name:     "Spring sale",
+		Template: "Hi {{name}}",
+		Status:   model.CampaignDraft,
+		Audience: []model.CampaignRecipient{
+			{To: "+905551112233", Vars: map[string]string{"name": "Ada"}},
+			{To: "+905554445566", Vars: map[string]string{"name": "Cem"}},
+		},
+	}
+	repo.On("GetByID", campaign.ID).Return(campaign, nil)
+	repo.On("Launch", campaign.ID, mock.MatchedBy(func(msgs []model.Message) bool {
+		return len(msgs) == 2 &&
+			msgs[0].Content == "Hi Ada" &&
+			msgs[1].Content == "Hi Cem" &&
+			*msgs[1].CampaignID == campaign.ID
+	})).Return(nil)
+
+	_, err := svc.Launch(campaign.ID)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestCampaignService_LaunchCancelledConflicts(t *testing.T) {
+	repo := new(MockCampaignRepository)
+	svc := service.NewCampaignService(repo)
+
+	id := uuid.New()
+	repo.On("GetByID", id).Return(&model.Campaign{ID: id, Status: model.CampaignCancelled}, nil)
+
+	_, err := svc.Launch(id)
+	assert.ErrorIs(t, err, repository.ErrConflict)
+}
+
+func TestCampaignService_GetProgress(t *testing.T) {
+	repo := new(MockCampaignRepository)
+	svc := service.NewCampaignService(repo)
+
+	id := uuid.New()
+	repo.On("GetByID", id).Return(&model.Campaign{ID: id, Status: model.CampaignRunning}, nil)
+	repo.On("CountMessages", id).Return(map[model.MessageStatus]int64{
+		model.StatusPending:    2,
+		model.StatusProcessing: 1,
+		model.StatusSent:       5,
+		model.StatusFailed:     1,
+		model.StatusCancelled:  1,
+	}, nil)
+
+	view, err := svc.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, service.CampaignProgress{Total: 10, Pending: 3, Sent: 5, Failed: 1, Cancelled: 1}, view.Progress)
+}
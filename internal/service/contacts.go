@@ -0,0 +1,264 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"insider-assessment/pkg/phone"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// segmentChunkSize is how many contacts are read at a time when a segment is expanded
+const segmentChunkSize = 500
+
+// maxExpandProblems caps the problems reported when a segment can't be expanded
+const maxExpandProblems = 10
+
+// ContactService manages contacts and the segments that select them
+type ContactService struct {
+	Contacts       repository.ContactRepository
+	Segments       repository.SegmentRepository
+	Suppressions   *SuppressionService // optional; opted-out contacts are left out of expansions
+	MaxSegmentSize int                 // most contacts an expansion may match; 0 means no limit
+}
+
+func NewContactService(contacts repository.ContactRepository, segments repository.SegmentRepository) *ContactService {
+	return &ContactService{Contacts: contacts, Segments: segments}
+}
+
+// SegmentView is a segment with the number of contacts it currently matches
+type SegmentView struct {
+	model.Segment
+	Contacts int64 `json:"contacts"`
+}
+
+// SegmentExpansion is the result of turning a segment into messages
+type SegmentExpansion struct {
+	Messages   []model.Message
+	Suppressed int // matching contacts left out because they opted out
+}
+
+// CreateContact validates and stores a contact. It returns repository.ErrConflict when
+// the number already is a contact.
+func (s *ContactService) CreateContact(contact *model.Contact) error {
+	if err := prepareContact(contact); err != nil {
+		return err
+	}
+	return s.Contacts.Create(contact)
+}
+
+// UpdateContact replaces every field of the contact
+func (s *ContactService) UpdateContact(contact *model.Contact) error {
+	if err := prepareContact(contact); err != nil {
+		return err
+	}
+	return s.Contacts.Update(contact)
+}
+
+func prepareContact(contact *model.Contact) error {
+	var problems []string
+	contact.Phone = phone.Normalize(contact.Phone)
+	if contact.Phone == "" {
+		problems = append(problems, "phone is required")
+	} else if !recipientPattern.MatchString(contact.Phone) {
+		problems = append(problems, fmt.Sprintf("invalid phone %q", contact.Phone))
+	}
+	if err := checkTimezone(contact.Timezone); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(contact.Locale) > 35 {
+		problems = append(problems, "locale is too long")
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	contact.Name = strings.TrimSpace(contact.Name)
+	if contact.Attributes == nil {
+		contact.Attributes = map[string]any{}
+	}
+	return nil
+}
+
+// CreateSegment validates and stores a segment
+func (s *ContactService) CreateSegment(segment *model.Segment) error {
+	if err := ValidateSegment(segment); err != nil {
+		return err
+	}
+	return s.Segments.Create(segment)
+}
+
+// UpdateSegment replaces the name and conditions of a segment
+func (s *ContactService) UpdateSegment(segment *model.Segment) error {
+	if err := ValidateSegment(segment); err != nil {
+		return err
+	}
+	return s.Segments.Update(segment)
+}
+
+// GetSegment returns the segment with the number of contacts it matches
+func (s *ContactService) GetSegment(id uuid.UUID) (*SegmentView, error) {
+	segment, err := s.Segments.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.Contacts.Count(segment.Conditions)
+	if err != nil {
+		return nil, err
+	}
+	return &SegmentView{Segment: *segment, Contacts: count}, nil
+}
+
+// ValidateSegment checks the name and conditions of a segment
+func ValidateSegment(segment *model.Segment) error {
+	var problems []string
+	segment.Name = strings.TrimSpace(segment.Name)
+	if segment.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	for i, cond := range segment.Conditions {
+		if err := checkCondition(cond); err != nil {
+			problems = append(problems, fmt.Sprintf("conditions[%d]: %s", i, err))
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	if segment.Conditions == nil {
+		segment.Conditions = []model.SegmentCondition{}
+	}
+	return nil
+}
+
+func checkCondition(cond model.SegmentCondition) error {
+	key, isAttribute := strings.CutPrefix(cond.Field, "attributes.")
+	if isAttribute && key == "" || !isAttribute && cond.Field != "locale" && cond.Field != "timezone" {
+		return fmt.Errorf("unknown field %q (use locale, timezone or attributes.<key>)", cond.Field)
+	}
+
+	switch cond.Op {
+	case model.OpEquals, model.OpNotEquals:
+		if cond.Value == nil {
+			return fmt.Errorf("%s needs a value", cond.Op)
+		}
+		if _, ok := cond.Value.(string); !ok && !isAttribute {
+			return fmt.Errorf("%s is compared with a string", cond.Field)
+		}
+	case model.OpIn:
+		values, ok := cond.Value.([]any)
+		if !ok || len(values) == 0 {
+			return fmt.Errorf("in needs a non-empty list of values")
+		}
+		for _, v := range values {
+			if _, ok := v.(string); !ok && !isAttribute {
+				return fmt.Errorf("%s is compared with strings", cond.Field)
+			}
+		}
+	case model.OpExists:
+	default:
+		return fmt.Errorf("unknown operator %q (use eq, ne, in or exists)", cond.Op)
+	}
+	return nil
+}
+
+// ExpandSegment renders the template for every contact the segment matches and returns the
+// messages, ready to be stored. Placeholders are filled from the contact's name, phone and
+// locale and its attributes. Opted-out contacts are left out. If the template can't be
+// rendered for some contact, or the segment matches more than MaxSegmentSize contacts,
+// nothing is returned. A segment matching nobody expands to no messages.
+func (s *ContactService) ExpandSegment(segmentID uuid.UUID, template, category string) (*SegmentExpansion, error) {
+	segment, err := s.Segments.GetByID(segmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	expansion := &SegmentExpansion{}
+	var problems []string
+	after := ""
+	matched := 0
+	for {
+		contacts, err := s.Contacts.Match(segment.Conditions, after, segmentChunkSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(contacts) == 0 {
+			break
+		}
+		after = contacts[len(contacts)-1].Phone
+		if matched += len(contacts); s.MaxSegmentSize > 0 && matched > s.MaxSegmentSize {
+			return nil, &ValidationError{Problems: []string{fmt.Sprintf(
+				"segment matches more than %d contacts; import the recipients from a file instead", s.MaxSegmentSize)}}
+		}
+
+		suppressed := map[string]bool{}
+		if s.Suppressions != nil {
+			phones := make([]string, len(contacts))
+			for i, contact := range contacts {
+				phones[i] = contact.Phone
+			}
+			if suppressed, err = s.Suppressions.Suppressed(phones); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, contact := range contacts {
+			if suppressed[contact.Phone] {
+				expansion.Suppressed++
+				continue
+			}
+			msg, err := buildContactMessage(contact, template, category)
+			if err != nil {
+				if len(problems) < maxExpandProblems {
+					problems = append(problems, fmt.Sprintf("contact %s: %s", contact.Phone, err))
+				}
+				continue
+			}
+			expansion.Messages = append(expansion.Messages, msg)
+		}
+		if len(contacts) < segmentChunkSize {
+			break
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return expansion, nil
+}
+
+func buildContactMessage(contact model.Contact, template, category string) (model.Message, error) {
+	vars := map[string]string{"name": contact.Name, "phone": contact.Phone, "locale": contact.Locale}
+	for key, value := range contact.Attributes {
+		vars[key] = attributeString(value)
+	}
+	content, err := RenderTemplate(template, vars)
+	if err != nil {
+		return model.Message{}, err
+	}
+
+	msg, err := buildMessage(contact.Phone, content)
+	if err != nil {
+		return model.Message{}, err
+	}
+	msg.Category = category
+	msg.Timezone = contact.Timezone
+	return msg, nil
+}
+
+// attributeString renders an attribute for a template: strings as they are, numbers and
+// booleans in their JSON form, anything else as JSON
+func attributeString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case float64, bool:
+		return fmt.Sprint(v)
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
@@ -0,0 +1,187 @@
+package service_test
+
+import (
+	"fmt"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/service"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockContactRepository is a mock implementation of repository.ContactRepository
+type MockContactRepository struct {
+	mock.Mock
+}
+
+func (m *MockContactRepository) Create(contact *model.Contact) error {
+	return m.Called(contact).Error(0)
+}
+
+func (m *MockContactRepository) GetByID(id uuid.UUID) (*model.Contact, error) {
+	args := m.Called(id)
+	contact, _ := args.Get(0).(*model.Contact)
+	return contact, args.Error(1)
+}
+
+func (m *MockContactRepository) Update(contact *model.Contact) error {
+	return m.Called(contact).Error(0)
+}
+
+func (m *MockContactRepository) Delete(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockContactRepository) List(cursor string, limit int) ([]model.Contact, string, error) {
+	args := m.Called(cursor, limit)
+	return args.Get(0).([]model.Contact), args.String(1), args.Error(2)
+}
+
+func (m *MockContactRepository) Match(conditions []model.SegmentCondition, afterPhone string, limit int) ([]model.Contact, error) {
+	args := m.Called(conditions, afterPhone, limit)
+	return args.Get(0).([]model.Contact), args.Error(1)
+}
+
+func (m *MockContactRepository) Count(conditions []model.SegmentCondition) (int64, error) {
+	args := m.Called(conditions)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockSegmentRepository is a mock implementation of repository.SegmentRepository
+type MockSegmentRepository struct {
+	mock.Mock
+}
+
+func (m *MockSegmentRepository) Create(segment *model.Segment) error {
+	return m.Called(segment).Error(0)
+}
+
+func (m *MockSegmentRepository) GetByID(id uuid.UUID) (*model.Segment, error) {
+	args := m.Called(id)
+	segment, _ := args.Get(0).(*model.Segment)
+	return segment, args.Error(1)
+}
+
+func (m *MockSegmentRepository) Update(segment *model.Segment) error {
+	return m.Called(segment).Error(0)
+}
+
+func (m *MockSegmentRepository) Delete(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockSegmentRepository) List() ([]model.Segment, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Segment), args.Error(1)
+}
+
+func TestContactService_CreateContactNormalizes(t *testing.T) {
+	contacts := new(MockContactRepository)
+	svc := service.NewContactService(contacts, new(MockSegmentRepository))
+
+	contacts.On("Create", mock.MatchedBy(func(c *model.Contact) bool {
+		return c.Phone == "+905551112233" && c.Name == "Ayşe" && c.Attributes != nil
+	})).Return(nil)
+	contact := model.Contact{Phone: "+90 555 111 22 33", Name: " Ayşe "}
+	require.NoError(t, svc.CreateContact(&contact))
+
+	err := svc.CreateContact(&model.Contact{Phone: "", Timezone: "Mars/Olympus"})
+	require.True(t, service.IsValidationError(err))
+	assert.Len(t, err.(*service.ValidationError).Problems, 2)
+	contacts.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestValidateSegment(t *testing.T) {
+	valid := model.Segment{Name: "gold", Conditions: []model.SegmentCondition{
+		{Field: "locale", Op: model.OpEquals, Value: "tr-TR"},
+		{Field: "attributes.tier", Op: model.OpIn, Value: []any{"gold", 3.0}},
+		{Field: "attributes.vip", Op: model.OpExists},
+	}}
+	require.NoError(t, service.ValidateSegment(&valid))
+
+	invalid := model.Segment{Conditions: []model.SegmentCondition{
+		{Field: "phone", Op: model.OpEquals, Value: "+905551112233"},
+		{Field: "attributes.", Op: model.OpExists},
+		{Field: "locale", Op: model.OpIn, Value: []any{}},
+		{Field: "timezone", Op: model.OpEquals, Value: 3.0},
+		{Field: "attributes.tier", Op: "gt", Value: 1.0},
+	}}
+	err := service.ValidateSegment(&invalid)
+	require.True(t, service.IsValidationError(err))
+	// the missing name and every condition
+	assert.Len(t, err.(*service.ValidationError).Problems, 6)
+}
+
+func TestContactService_ExpandSegment(t *testing.T) {
+	contacts := new(MockContactRepository)
+	segments := new(MockSegmentRepository)
+	suppressions := new(MockSuppressionRepository)
+	svc := service.NewContactService(contacts, segments)
+	svc.Suppressions = service.NewSuppressionService(suppressions, "STOP", "START")
+
+	segment := model.Segment{ID: uuid.New(), Conditions: []model.SegmentCondition{{Field: "attributes.tier", Op: model.OpEquals, Value: "gold"}}}
+	segments.On("GetByID", segment.ID).Return(&segment, nil)
+	contacts.On("Match", segment.Conditions, "", 500).Return([]model.Contact{
+		{Phone: "+905551112233", Name: "Ayşe", Timezone: "Europe/Istanbul", Attributes: map[string]any{"tier": "gold", "points": 1200.0}},
+		{Phone: "+905551112234", Name: "Can", Attributes: map[string]any{"tier": "gold", "points": 5.5}},
+	}, nil)
+	suppressions.On("Suppressed", []string{"+905551112233", "+905551112234"}).Return(map[string]bool{"+905551112234": true}, nil)
+
+	expansion, err := svc.ExpandSegment(segment.ID, "Hi {{name}}, you have {{points}} {{tier}} points", model.CategoryMarketing)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expansion.Suppressed)
+	require.Len(t, expansion.Messages, 1)
+	msg := expansion.Messages[0]
+	assert.Equal(t, "+905551112233", msg.To)
+	assert.Equal(t, "Hi Ayşe, you have 1200 gold points", msg.Content)
+	assert.Equal(t, model.StatusPending, msg.Status)
+	assert.Equal(t, model.CategoryMarketing, msg.Category)
+	assert.Equal(t, "Europe/Istanbul", msg.Timezone)
+
+	// a placeholder missing for a contact fails the whole expansion
+	_, err = svc.ExpandSegment(segment.ID, "Hi {{nickname}}", "")
+	assert.True(t, service.IsValidationError(err))
+}
+
+func TestContactService_ExpandEmptySegment(t *testing.T) {
+	contacts := new(MockContactRepository)
+	segments := new(MockSegmentRepository)
+	svc := service.NewContactService(contacts, segments)
+
+	segment := model.Segment{ID: uuid.New(), Conditions: []model.SegmentCondition{}}
+	segments.On("GetByID", segment.ID).Return(&segment, nil)
+	contacts.On("Match", segment.Conditions, "", 500).Return([]model.Contact{}, nil)
+
+	expansion, err := svc.ExpandSegment(segment.ID, "Hello", "")
+	require.NoError(t, err)
+	assert.Empty(t, expansion.Messages)
+	assert.Zero(t, expansion.Suppressed)
+}
+
+func TestContactService_ExpandSegmentOverLimit(t *testing.T) {
+	contacts := new(MockContactRepository)
+	segments := new(MockSegmentRepository)
+	svc := service.NewContactService(contacts, segments)
+	svc.MaxSegmentSize = 600
+
+	chunk := func(first int) []model.Contact {
+		page := make([]model.Contact, 500)
+		for i := range page {
+			page[i] = model.Contact{Phone: fmt.Sprintf("+90555%07d", first+i), Name: "Ayşe"}
+		}
+		return page
+	}
+	segment := model.Segment{ID: uuid.New(), Conditions: []model.SegmentCondition{}}
+	segments.On("GetByID", segment.ID).Return(&segment, nil)
+	contacts.On("Match", segment.Conditions, "", 500).Return(chunk(0), nil)
+	contacts.On("Match", segment.Conditions, "+905550000499", 500).Return(chunk(500), nil)
+
+	// the expansion stops at the chunk that crosses the limit
+	_, err := svc.ExpandSegment(segment.ID, "Hi {{name}}", "")
+	require.True(t, service.IsValidationError(err))
+	assert.Contains(t, err.Error(), "more than 600 contacts")
+	contacts.AssertNumberOfCalls(t, "Match", 2)
+}
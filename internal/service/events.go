@@ -0,0 +1,151 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the body, keyed with the subscription's secret
+const SignatureHeader = "X-Event-Signature"
+
+// EventPublisher fans lifecycle events out to a Redis Pub/Sub channel and to the registered
+// HTTP subscriptions. It is driven by the outbox relay, which retries an event for the targets
+// Deliver didn't reach, so subscribers may see an event more than once after a crash and
+// should deduplicate on its ID.
+type EventPublisher struct {
+	Redis         *redis.Client // nil skips Pub/Sub
+	Channel       string
+	Subscriptions repository.EventSubscriptionRepository // nil skips webhooks
+	Client        *http.Client
+}
+
+func NewEventPublisher(rdb *redis.Client, channel string, subs repository.EventSubscriptionRepository) *EventPublisher {
+	return &EventPublisher{
+		Redis:         rdb,
+		Channel:       channel,
+		Subscriptions: subs,
+		Client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// LoadSubscriptions returns the registered subscriptions, none when webhooks are disabled
+func (p *EventPublisher) LoadSubscriptions() ([]model.EventSubscription, error) {
+	if p.Subscriptions == nil {
+		return nil, nil
+	}
+	return p.Subscriptions.List()
+}
+
+// Deliver publishes the event to Redis and posts it to every subscription that wants it,
+// skipping the targets in delivered, which got it in an earlier attempt. It returns the
+// targets reached by this call (model.TargetPubSub and subscription IDs) and fails if any
+// target failed; the caller then retries the event for the remaining ones.
+func (p *EventPublisher) Deliver(ctx context.Context, event model.Event, subs []model.EventSubscription, delivered []string) ([]string, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var reached []string
+	var errs []error
+	if p.Redis != nil && !slices.Contains(delivered, model.TargetPubSub) {
+		if err := p.Redis.Publish(ctx, p.Channel, body).Err(); err != nil {
+			errs = append(errs, fmt.Errorf("publish: %w", err))
+		} else {
+			reached = append(reached, model.TargetPubSub)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, sub := range subs {
+		if !sub.Wants(event.Type) || slices.Contains(delivered, sub.ID.String()) {
+			continue
+		}
+		wg.Add(1)
+		go func(sub model.EventSubscription) {
+			defer wg.Done()
+			status, err := p.post(ctx, sub, event, body)
+			if err == nil && status >= 300 {
+				err = fmt.Errorf("status %d", status)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("subscription %s: %w", sub.ID, err))
+			} else {
+				reached = append(reached, sub.ID.String())
+			}
+		}(sub)
+	}
+	wg.Wait()
+	return reached, errors.Join(errs...)
+}
+
+func (p *EventPublisher) post(ctx context.Context, sub model.EventSubscription, event model.Event, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", event.ID.String())
+	req.Header.Set("X-Event-Type", string(event.Type))
+	if sub.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(sub.Secret, body))
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body, as sent in SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateSubscription checks the endpoint and event types of a new subscription
+func ValidateSubscription(sub *model.EventSubscription) error {
+	var problems []string
+	if u, err := url.Parse(sub.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, "url must be an absolute http(s) URL")
+	}
+	for _, t := range sub.Events {
+		if !knownEventType(t) {
+			problems = append(problems, fmt.Sprintf("unknown event type %q", t))
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func knownEventType(t model.EventType) bool {
+	for _, known := range model.EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
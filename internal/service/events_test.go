@@ -0,0 +1,266 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"insider-assessment/internal/service"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSubscriptionRepository is a mock implementation of repository.EventSubscriptionRepository
+type MockSubscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSubscriptionRepository) Create(sub *model.EventSubscription) error {
+	return m.Called(sub).Error(0)
+}
+
+func (m *MockSubscriptionRepository) List() ([]model.EventSubscription, error) {
+	args := m.Called()
+	return args.Get(0).([]model.EventSubscription), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) Delete(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+// memoryOutbox is an in-memory repository.OutboxRepository that ignores next_attempt_at
+type memoryOutbox struct {
+	rows  []model.OutboxEvent
+	lease time.Duration // of the last Lease call
+}
+
+func (o *memoryOutbox) Lease(limit, maxAttempts int, lease time.Duration) ([]model.OutboxEvent, error) {
+	o.lease = lease
+	var leased []model.OutboxEvent
+	for i := range o.rows {
+		row := &o.rows[i]
+		if row.DeliveredAt != nil || row.Attempts >= maxAttempts || len(leased) == limit {
+			continue
+		}
+		row.Attempts++
+		row.NextAttemptAt = time.Now().Add(lease)
+		leased = append(leased, *row)
+	}
+	return leased, nil
+}
+
+func (o *memoryOutbox) row(id uint64) *model.OutboxEvent {
+	for i := range o.rows {
+		if o.rows[i].ID == id {
+			return &o.rows[i]
+		}
+	}
+	return nil
+}
+
+func (o *memoryOutbox) MarkDelivered(id uint64, leasedUntil time.Time, deliveredTo []string) error {
+	now := time.Now()
+	row := o.row(id)
+	if !row.NextAttemptAt.Equal(leasedUntil) {
+		return repository.ErrConflict
+	}
+	row.DeliveredAt, row.DeliveredTo, row.LastError = &now, deliveredTo, ""
+	return nil
+}
+
+func (o *memoryOutbox) MarkFailed(id uint64, leasedUntil time.Time, deliveredTo []string, lastError string, nextAttemptAt time.Time) error {
+	row := o.row(id)
+	if !row.NextAttemptAt.Equal(leasedUntil) {
+		return repository.ErrConflict
+	}
+	row.DeliveredTo, row.LastError, row.NextAttemptAt = deliveredTo, lastError, nextAttemptAt
+	return nil
+}
+
+func (o *memoryOutbox) Purge(before time.Time, maxAttempts int) (int64, error) {
+	return 0, nil
+}
+
+func TestOutboxRelay_RetriesUntilDelivered(t *testing.T) {
+	var calls atomic.Int32
+	delivered := make(chan model.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "sha256="+service.Sign("s3cret", body), r.Header.Get(service.SignatureHeader))
+		assert.Equal(t, "message.sent", r.Header.Get("X-Event-Type"))
+
+		var event model.Event
+		require.NoError(t, json.Unmarshal(body, &event))
+		delivered <- event
+	}))
+	defer server.Close()
+
+	subs := new(MockSubscriptionRepository)
+	subs.On("List").Return([]model.EventSubscription{
+		{ID: uuid.New(), URL: server.URL, Events: []model.EventType{model.EventMessageSent}, Secret: "s3cret"},
+		{ID: uuid.New(), URL: server.URL + "/created-only", Events: []model.EventType{model.EventMessageCreated}},
+	}, nil)
+
+	msg := model.Message{ID: uuid.New(), To: "+905551112233", Status: model.StatusSent}
+	event := model.NewMessageEvent(model.EventMessageSent, msg)
+	outbox := &memoryOutbox{rows: []model.OutboxEvent{model.NewOutboxEvent(event)}}
+	relay := service.NewOutboxRelay(outbox, service.NewEventPublisher(nil, "events", subs), time.Second, 3, time.Minute, 0)
+
+	relayed, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, relayed)
+	assert.Nil(t, outbox.rows[0].DeliveredAt)
+	assert.Contains(t, outbox.rows[0].LastError, "status 503")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), outbox.rows[0].NextAttemptAt, 5*time.Second)
+
+	relayed, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	assert.NotNil(t, outbox.rows[0].DeliveredAt)
+
+	got := <-delivered
+	assert.Equal(t, event.ID, got.ID)
+	assert.Equal(t, msg.ID, got.Data.MessageID)
+	assert.Equal(t, int32(2), calls.Load()) // one failure, one retry; the other subscription doesn't want it
+}
+
+func TestOutboxRelay_GivesUpAfterMaxAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	subs := new(MockSubscriptionRepository)
+	subs.On("List").Return([]model.EventSubscription{{ID: uuid.New(), URL: server.URL}}, nil)
+
+	event := model.NewMessageEvent(model.EventMessageFailed, model.Message{ID: uuid.New(), Status: model.StatusFailed})
+	outbox := &memoryOutbox{rows: []model.OutboxEvent{model.NewOutboxEvent(event)}}
+	relay := service.NewOutboxRelay(outbox, service.NewEventPublisher(nil, "events", subs), time.Second, 2, time.Minute, 0)
+
+	for i := 0; i < 4; i++ {
+		_, err := relay.RelayOnce(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, outbox.rows[0].Attempts)
+	assert.Nil(t, outbox.rows[0].DeliveredAt)
+}
+
+func TestOutboxRelay_LeaseOutlastsTheBatch(t *testing.T) {
+	subs := new(MockSubscriptionRepository)
+	subs.On("List").Return([]model.EventSubscription{}, nil)
+	outbox := &memoryOutbox{}
+	relay := service.NewOutboxRelay(outbox, service.NewEventPublisher(nil, "events", subs), time.Second, 3, time.Minute, 0)
+
+	// every event of a full batch may wait for the webhook timeout
+	_, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 100*10*time.Second+time.Minute, outbox.lease)
+
+	relay.BatchSize = 10
+	_, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, outbox.lease)
+}
+
+func TestOutboxRelay_LeavesEventsWhoseLeaseRanOut(t *testing.T) {
+	var outbox *memoryOutbox
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the call took so long that another relay leased the event again
+		outbox.rows[0].NextAttemptAt = time.Now().Add(time.Hour)
+		outbox.rows[0].Attempts++
+	}))
+	defer server.Close()
+
+	subs := new(MockSubscriptionRepository)
+	subs.On("List").Return([]model.EventSubscription{{ID: uuid.New(), URL: server.URL}}, nil)
+
+	event := model.NewMessageEvent(model.EventMessageSent, model.Message{ID: uuid.New(), Status: model.StatusSent})
+	outbox = &memoryOutbox{rows: []model.OutboxEvent{model.NewOutboxEvent(event)}}
+	relay := service.NewOutboxRelay(outbox, service.NewEventPublisher(nil, "events", subs), time.Second, 3, time.Minute, 0)
+
+	relayed, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, relayed)
+	// the outcome is left to the relay holding the lease
+	assert.Nil(t, outbox.rows[0].DeliveredAt)
+	assert.Empty(t, outbox.rows[0].DeliveredTo)
+	assert.Equal(t, 2, outbox.rows[0].Attempts)
+}
+
+func TestValidateSubscription(t *testing.T) {
+	assert.NoError(t, service.ValidateSubscription(&model.EventSubscription{URL: "https://example.com/hook"}))
+
+	err := service.ValidateSubscription(&model.EventSubscription{URL: "ftp://example.com", Events: []model.EventType{"message.read"}})
+	require.True(t, service.IsValidationError(err))
+	assert.Len(t, err.(*service.ValidationError).Problems, 2)
+}
+
+func TestOutboxRelay_RetriesOnlyTheTargetsThatFailed(t *testing.T) {
+	var healthyCalls, flakyCalls atomic.Int32
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyCalls.Add(1)
+	}))
+	defer healthy.Close()
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if flakyCalls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer flaky.Close()
+
+	rdb := newTestRedis(t)
+	pubsub := rdb.Subscribe(context.Background(), "events")
+	defer pubsub.Close()
+	_, err := pubsub.Receive(context.Background())
+	require.NoError(t, err)
+
+	healthySub := model.EventSubscription{ID: uuid.New(), URL: healthy.URL}
+	flakySub := model.EventSubscription{ID: uuid.New(), URL: flaky.URL}
+	subs := new(MockSubscriptionRepository)
+	subs.On("List").Return([]model.EventSubscription{healthySub, flakySub}, nil)
+
+	event := model.NewMessageEvent(model.EventMessageSent, model.Message{ID: uuid.New(), Status: model.StatusSent})
+	row := model.NewOutboxEvent(event)
+	row.ID = 1
+	outbox := &memoryOutbox{rows: []model.OutboxEvent{row}}
+	relay := service.NewOutboxRelay(outbox, service.NewEventPublisher(rdb, "events", subs), time.Second, 3, time.Minute, 0)
+
+	relayed, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, relayed)
+	assert.ElementsMatch(t, []string{model.TargetPubSub, healthySub.ID.String()}, outbox.rows[0].DeliveredTo)
+	assert.Contains(t, outbox.rows[0].LastError, flakySub.ID.String())
+
+	relayed, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	assert.ElementsMatch(t, []string{model.TargetPubSub, healthySub.ID.String(), flakySub.ID.String()}, outbox.rows[0].DeliveredTo)
+
+	// the retry only went to the subscription that failed
+	assert.Equal(t, int32(1), healthyCalls.Load())
+	assert.Equal(t, int32(2), flakyCalls.Load())
+	published := 0
+	for {
+		select {
+		case <-pubsub.Channel():
+			published++
+			continue
+		case <-time.After(50 * time.Millisecond):
+		}
+		break
+	}
+	assert.Equal(t, 1, published)
+}
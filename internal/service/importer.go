@@ -0,0 +1,415 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"insider-assessment/pkg/phone"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// ErrUnsupportedFormat is returned when the upload is neither CSV nor NDJSON
+var ErrUnsupportedFormat = errors.New("unsupported import format, expected csv or ndjson")
+
+var recipientPattern = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
+// NormalizeRecipient returns a number in the form messages are stored with, so it can be
+// used to look them up, or a ValidationError if it isn't a phone number
+func NormalizeRecipient(number string) (string, error) {
+	normalized := phone.Normalize(number)
+	if !recipientPattern.MatchString(normalized) {
+		return "", &ValidationError{Problems: []string{fmt.Sprintf("invalid recipient %q", strings.TrimSpace(number))}}
+	}
+	return normalized, nil
+}
+
+// finishAttempts is how often the final state of an import is written before giving up
+const finishAttempts = 3
+
+// finishRetryDelay grows linearly between those attempts
+const finishRetryDelay = 100 * time.Millisecond
+
+// ImportService turns uploaded recipient files into pending messages
+type ImportService struct {
+	Messages  repository.MessageRepository
+	Jobs      repository.ImportRepository
+	ChunkSize int
+	Queue     Queue // optional; imported messages are announced to it chunk by chunk
+}
+
+func NewImportService(messages repository.MessageRepository, jobs repository.ImportRepository, chunkSize int) *ImportService {
+	if chunkSize <= 0 {
+		chunkSize = 500
+	}
+	return &ImportService{
+		Messages:  messages,
+		Jobs:      jobs,
+		ChunkSize: chunkSize,
+	}
+}
+
+type ImportOptions struct {
+	Filename string
+	Format   string // csv or ndjson, detected from Filename when empty
+	Template string // used for rows without a content column
+}
+
+// DetectImportFormat resolves the format from an explicit value or the file extension
+func DetectImportFormat(filename, format string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+
+	switch strings.ToLower(format) {
+	case "csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Import reads the file row by row, writes valid rows in chunks and records the rest
+// as row errors. Row numbers are 1-based and exclude the CSV header.
+func (s *ImportService) Import(r io.Reader, opts ImportOptions) (*model.ImportJob, error) {
+	format, err := DetectImportFormat(opts.Filename, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.ImportJob{
+		Filename: opts.Filename,
+		Format:   format,
+		Status:   model.ImportProcessing,
+	}
+	if err := s.Jobs.Create(job); err != nil {
+		return nil, err
+	}
+
+	batch := &importBatch{svc: s, job: job}
+	if err := batch.run(newRowReader(r, format), opts.Template); err != nil {
+		slog.Error("import aborted", "import_id", job.ID, "error", err)
+		job.Status = model.ImportFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = model.ImportCompleted
+	}
+
+	now := time.Now()
+	job.CompletedAt = &now
+	if err := s.finish(job); err != nil {
+		return nil, err
+	}
+
+	slog.Info("import finished", "import_id", job.ID, "status", job.Status,
+		"total", job.TotalRows, "imported", job.ImportedRows, "failed", job.FailedRows)
+	return job, nil
+}
+
+// finish stores the final state of the job. A job left in PROCESSING looks like it is still
+// running, so the update is retried and, if it keeps failing, the job is marked FAILED with
+// a narrower update as a last resort.
+func (s *ImportService) finish(job *model.ImportJob) error {
+	var err error
+	for attempt := 0; attempt < finishAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * finishRetryDelay)
+		}
+		if err = s.Jobs.Update(job); err == nil {
+			return nil
+		}
+		slog.Warn("failed to store import result", "import_id", job.ID, "attempt", attempt+1, "error", err)
+	}
+
+	if failErr := s.Jobs.Fail(job.ID, "failed to store the import result: "+err.Error()); failErr != nil {
+		slog.Error("failed to mark import as failed", "import_id", job.ID, "error", failErr)
+	}
+	return err
+}
+
+// WriteImportErrorReport renders row errors as a CSV report
+func WriteImportErrorReport(w io.Writer, errs []model.ImportRowError) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"row", "to", "reason"}); err != nil {
+		return err
+	}
+	for _, e := range errs {
+		if err := cw.Write([]string{strconv.Itoa(e.RowNumber), e.To, e.Reason}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// importBatch buffers valid messages and rejected rows between chunk flushes
+type importBatch struct {
+	svc      *ImportService
+	job      *model.ImportJob
+	messages []model.Message
+	rows     []int
+	errs     []model.ImportRowError
+}
+
+func (b *importBatch) run(rows rowReader, tmpl string) error {
+	for rowNum := 1; ; rowNum++ {
+		vars, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+
+		var rowErr *rowError
+		if errors.As(err, &rowErr) {
+			b.job.TotalRows++
+			b.reject(rowNum, "", rowErr.Error())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		b.job.TotalRows++
+		msg, err := buildImportMessage(vars, tmpl)
+		if err != nil {
+			b.reject(rowNum, vars["to"], err.Error())
+			continue
+		}
+
+		b.messages = append(b.messages, msg)
+		b.rows = append(b.rows, rowNum)
+		if len(b.messages) >= b.svc.ChunkSize {
+			if err := b.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return b.flush()
+}
+
+func (b *importBatch) reject(row int, to, reason string) {
+	b.job.FailedRows++
+	b.errs = append(b.errs, model.ImportRowError{
+		ImportJobID: b.job.ID,
+		RowNumber:   row,
+		To:          to,
+		Reason:      reason,
+	})
+}
+
+// announce hands a written chunk to the queue. The rows are committed, so a queue failure
+// only means the worker finds them by polling.
+func (b *importBatch) announce() {
+	if b.svc.Queue == nil {
+		return
+	}
+	ids := make([]uuid.UUID, len(b.messages))
+	for i, msg := range b.messages {
+		ids[i] = msg.ID
+	}
+	if err := b.svc.Queue.Enqueue(context.Background(), ids...); err != nil {
+		slog.Warn("failed to enqueue imported messages", "import_id", b.job.ID, "error", err)
+	}
+}
+
+// flush writes the buffered chunk. A failed insert rejects the whole chunk instead of the import.
+func (b *importBatch) flush() error {
+	if len(b.messages) > 0 {
+		if err := b.svc.Messages.CreateBatch(b.messages); err != nil {
+			slog.Error("failed to write import chunk", "import_id", b.job.ID, "error", err)
+			for i, row := range b.rows {
+				b.reject(row, b.messages[i].To, "database error: "+err.Error())
+			}
+		} else {
+			b.job.ImportedRows += len(b.messages)
+			b.announce()
+		}
+		b.messages = b.messages[:0]
+		b.rows = b.rows[:0]
+	}
+
+	if err := b.svc.Jobs.AddErrors(b.errs); err != nil {
+		return fmt.Errorf("failed to store row errors: %w", err)
+	}
+	b.errs = b.errs[:0]
+
+	return b.svc.Jobs.Update(b.job)
+}
+
+func buildImportMessage(vars map[string]string, tmpl string) (model.Message, error) {
+	content := vars["content"]
+	if content == "" {
+		if tmpl == "" {
+			return model.Message{}, errors.New("content is required when no template is given")
+		}
+		rendered, err := RenderTemplate(tmpl, vars)
+		if err != nil {
+			return model.Message{}, err
+		}
+		content = rendered
+	}
+
+	msg, err := buildMessage(vars["to"], content)
+	if err != nil {
+		return model.Message{}, err
+	}
+	// optional columns for quiet hours
+	msg.Category = model.NormalizeCategory(vars["category"])
+	msg.Timezone = strings.TrimSpace(vars["timezone"])
+	if err := checkCategory(msg.Category); err != nil {
+		return model.Message{}, err
+	}
+	if err := checkTimezone(msg.Timezone); err != nil {
+		return model.Message{}, err
+	}
+	return msg, nil
+}
+
+// buildMessage validates the recipient and content of a new pending message
+func buildMessage(to, content string) (model.Message, error) {
+	normalized := phone.Normalize(to)
+	if normalized == "" {
+		return model.Message{}, errors.New("to is required")
+	}
+	if !recipientPattern.MatchString(normalized) {
+		return model.Message{}, fmt.Errorf("invalid recipient %q", strings.TrimSpace(to))
+	}
+	if len(content) > model.MaxContentLength {
+		return model.Message{}, errors.New("message content exceeds 160 characters")
+	}
+
+	return model.Message{
+		To:      normalized,
+		Content: content,
+		Status:  model.StatusPending,
+	}, nil
+}
+
+// rowReader yields one row at a time as column -> value, returning io.EOF at the end.
+// Problems confined to a single row are returned as *rowError so the import can continue.
+type rowReader interface {
+	Next() (map[string]string, error)
+}
+
+type rowError struct {
+	err error
+}
+
+func (e *rowError) Error() string { return e.err.Error() }
+
+func newRowReader(r io.Reader, format string) rowReader {
+	if format == FormatNDJSON {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		return &ndjsonRowReader{scanner: scanner}
+	}
+
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	return &csvRowReader{reader: cr}
+}
+
+type csvRowReader struct {
+	reader *csv.Reader
+	header []string
+}
+
+func (c *csvRowReader) Next() (map[string]string, error) {
+	if c.header == nil {
+		header, err := c.reader.Read()
+		if err == io.EOF {
+			return nil, errors.New("csv file is empty")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv header: %w", err)
+		}
+		for i := range header {
+			header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+		}
+		c.header = header
+	}
+
+	record, err := c.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, &rowError{err: parseErr.Err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]string, len(c.header))
+	for i, col := range c.header {
+		row[col] = strings.TrimSpace(record[i])
+	}
+	return row, nil
+}
+
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+}
+
+func (n *ndjsonRowReader) Next() (map[string]string, error) {
+	for n.scanner.Scan() {
+		line := strings.TrimSpace(n.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var raw map[string]any
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.UseNumber() // keep phone numbers out of float64
+		if err := dec.Decode(&raw); err != nil {
+			return nil, &rowError{err: fmt.Errorf("invalid json: %w", err)}
+		}
+
+		row := make(map[string]string, len(raw))
+		for key, val := range raw {
+			// template variables may be nested under "vars"
+			if nested, ok := val.(map[string]any); ok && key == "vars" {
+				for k, v := range nested {
+					row[k] = stringify(v)
+				}
+				continue
+			}
+			row[key] = stringify(val)
+		}
+		return row, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func stringify(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"bytes"
+	"errors"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockImportRepository is a mock implementation of repository.ImportRepository
+type MockImportRepository struct {
+	mock.Mock
+	Errors []model.ImportRowError
+}
+
+func (m *MockImportRepository) Create(job *model.ImportJob) error {
+	job.ID = uuid.New()
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockImportRepository) Update(job *model.ImportJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockImportRepository) Fail(id uuid.UUID, reason string) error {
+	args := m.Called(id, reason)
+	return args.Error(0)
+}
+
+func (m *MockImportRepository) GetByID(id uuid.UUID) (*model.ImportJob, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.ImportJob), args.Error(1)
+}
+
+func (m *MockImportRepository) AddErrors(errs []model.ImportRowError) error {
+	m.Errors = append(m.Errors, errs...)
+	return nil
+}
+
+func (m *MockImportRepository) GetErrors(jobID uuid.UUID) ([]model.ImportRowError, error) {
+	return m.Errors, nil
+}
+
+func TestRenderTemplate(t *testing.T) {
+	out, err := service.RenderTemplate("Hi {{name}}, code {{ code }}", map[string]string{"name": "Ada", "code": "42"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hi Ada, code 42", out)
+
+	_, err = service.RenderTemplate("Hi {{name}}", map[string]string{})
+	assert.EqualError(t, err, "missing template variables: name")
+}
+
+func TestImportService_CSV(t *testing.T) {
+	msgRepo := new(MockRepository)
+	jobRepo := new(MockImportRepository)
+
+	var written [][]model.Message
+	msgRepo.On("CreateBatch", mock.Anything).Run(func(args mock.Arguments) {
+		chunk := args.Get(0).([]model.Message)
+		written = append(written, append([]model.Message(nil), chunk...))
+	}).Return(nil)
+	jobRepo.On("Create", mock.Anything).Return(nil)
+	jobRepo.On("Update", mock.Anything).Return(nil)
+
+	csv := "to,content,name\n" +
+		"+905551112233,,Ada\n" +
+		"not-a-phone,Hello,Bob\n" +
+		"+905554445566,Custom text,Cem\n" +
+		"+905557778899,,Deniz\n"
+
+	svc := service.NewImportService(msgRepo, jobRepo, 2)
+	job, err := svc.Import(strings.NewReader(csv), service.ImportOptions{
+		Filename: "recipients.csv",
+		Template: "Hi {{name}}",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.ImportCompleted, job.Status)
+	assert.Equal(t, 4, job.TotalRows)
+	assert.Equal(t, 3, job.ImportedRows)
+	assert.Equal(t, 1, job.FailedRows)
+
+	// chunk size 2 -> two inserts
+	assert.Len(t, written, 2)
+	assert.Equal(t, "Hi Ada", written[0][0].Content)
+	assert.Equal(t, "Custom text", written[0][1].Content)
+	assert.Equal(t, "Hi Deniz", written[1][0].Content)
+
+	assert.Len(t, jobRepo.Errors, 1)
+	assert.Equal(t, 2, jobRepo.Errors[0].RowNumber)
+
+	var report bytes.Buffer
+	assert.NoError(t, service.WriteImportErrorReport(&report, jobRepo.Errors))
+	assert.Equal(t, "row,to,reason\n2,not-a-phone,\"invalid recipient \"\"not-a-phone\"\"\"\n", report.String())
+}
+
+func TestImportService_NDJSON(t *testing.T) {
+	msgRepo := new(MockRepository)
+	jobRepo := new(MockImportRepository)
+
+	msgRepo.On("CreateBatch", mock.Anything).Return(errors.New("db down")).Once()
+	jobRepo.On("Create", mock.Anything).Return(nil)
+	jobRepo.On("Update", mock.Anything).Return(nil)
+
+	ndjson := `{"to": 905551112233, "vars": {"name": "Ada"}}` + "\n\n" +
+		`{"to": "+905554445566", "content": "` + strings.Repeat("x", 161) + `"}` + "\n" +
+		`{broken` + "\n"
+
+	svc := service.NewImportService(msgRepo, jobRepo, 100)
+	job, err := svc.Import(strings.NewReader(ndjson), service.ImportOptions{
+		Format:   "ndjson",
+		Template: "Hi {{name}}",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, job.TotalRows)
+	assert.Equal(t, 0, job.ImportedRows)
+	assert.Equal(t, 3, job.FailedRows)
+	assert.Contains(t, jobRepo.Errors[2].Reason, "database error")
+	// the numeric "to" is stored in E.164 form
+	assert.Equal(t, "+905551112233", jobRepo.Errors[2].To)
+}
+
+func TestImportService_UnsupportedFormat(t *testing.T) {
+	svc := service.NewImportService(new(MockRepository), new(MockImportRepository), 10)
+	_, err := svc.Import(strings.NewReader(""), service.ImportOptions{Filename: "people.xlsx"})
+	assert.ErrorIs(t, err, service.ErrUnsupportedFormat)
+}
+
+func TestImportService_FailsJobWhenResultCannotBeStored(t *testing.T) {
+	msgRepo := new(MockRepository)
+	jobRepo := new(MockImportRepository)
+
+	msgRepo.On("CreateBatch", mock.Anything).Return(nil)
+	jobRepo.On("Create", mock.Anything).Return(nil)
+	// the chunk flush succeeds, every write of the final state fails
+	jobRepo.On("Update", mock.Anything).Return(nil).Once()
+	jobRepo.On("Update", mock.Anything).Return(errors.New("connection reset"))
+	jobRepo.On("Fail", mock.Anything, mock.MatchedBy(func(reason string) bool {
+		return strings.Contains(reason, "connection reset")
+	})).Return(nil)
+
+	svc := service.NewImportService(msgRepo, jobRepo, 10)
+	_, err := svc.Import(strings.NewReader("to,content\n+905551112233,Hi\n"), service.ImportOptions{Format: "csv"})
+
+	assert.EqualError(t, err, "connection reset")
+	jobRepo.AssertNumberOfCalls(t, "Update", 4)
+	jobRepo.AssertNumberOfCalls(t, "Fail", 1)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"insider-assessment/pkg/phone"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Conversation directions
+const (
+	DirectionOutbound = "outbound"
+	DirectionInbound  = "inbound"
+)
+
+// InboundService stores the replies recipients send and shows them next to what we sent
+type InboundService struct {
+	Repo         repository.InboundRepository
+	Suppressions *SuppressionService // optional; replies are checked for opt-out keywords
+}
+
+func NewInboundService(repo repository.InboundRepository, suppressions *SuppressionService) *InboundService {
+	return &InboundService{Repo: repo, Suppressions: suppressions}
+}
+
+// ConversationEntry is one message of a thread, in either direction
+type ConversationEntry struct {
+	ID         uuid.UUID  `json:"id"`
+	Direction  string     `json:"direction" enums:"outbound,inbound"`
+	Content    string     `json:"content"`
+	At         time.Time  `json:"at"`                    // sent_at or received_at
+	CampaignID *uuid.UUID `json:"campaign_id,omitempty"` // outbound only
+	InReplyTo  *uuid.UUID `json:"in_reply_to,omitempty"` // inbound only
+}
+
+// Conversation is a page of a thread, newest first
+type Conversation struct {
+	Phone      string              `json:"phone"`
+	Entries    []ConversationEntry `json:"entries"`
+	NextCursor string              `json:"next_cursor,omitempty"` // pass as cursor for older entries
+}
+
+// Receive stores a reply, links it to the last message sent to the number, and applies
+// opt-out keywords. A reply redelivered under the same remote ID is stored once: the stored
+// reply is returned and its keyword isn't applied again.
+func (s *InboundService) Receive(from, content, remoteID string) (*model.InboundMessage, KeywordAction, error) {
+	normalized := phone.Normalize(from)
+	if normalized == "" {
+		return nil, KeywordNone, &ValidationError{Problems: []string{"from is required"}}
+	}
+
+	inReplyTo, err := s.Repo.LastSent(recipientForms(from))
+	if err != nil {
+		return nil, KeywordNone, err
+	}
+	msg := model.InboundMessage{
+		From:      normalized,
+		Content:   content,
+		RemoteID:  strings.TrimSpace(remoteID),
+		InReplyTo: inReplyTo,
+	}
+	created, err := s.Repo.Create(&msg)
+	if err != nil {
+		return nil, KeywordNone, err
+	}
+	if !created {
+		// answer with the reply stored on the first delivery
+		existing, err := s.Repo.ByRemoteID(msg.RemoteID)
+		if err != nil {
+			return nil, KeywordNone, err
+		}
+		return existing, KeywordNone, nil
+	}
+	if s.Suppressions == nil {
+		return &msg, KeywordNone, nil
+	}
+
+	action, err := s.Suppressions.HandleInbound(normalized, content)
+	if err != nil {
+		return nil, KeywordNone, err
+	}
+	return &msg, action, nil
+}
+
+// Conversation returns up to limit entries exchanged with the number, newest first, starting
+// after the entry the cursor points at ("" for the newest). Entries are ordered by time and
+// then ID, so entries sharing a timestamp are neither repeated nor skipped across pages. Only
+// delivered outbound messages are part of a thread.
+func (s *InboundService) Conversation(number, cursor string, limit int) (*Conversation, error) {
+	normalized := phone.Normalize(number)
+	if normalized == "" {
+		return nil, &ValidationError{Problems: []string{"phone is required"}}
+	}
+	var before *repository.Position
+	if cursor != "" {
+		position, err := repository.ParsePosition(cursor)
+		if err != nil {
+			return nil, &ValidationError{Problems: []string{"invalid cursor"}}
+		}
+		before = &position
+	}
+
+	// one more than a page from each side tells whether anything older is left
+	sent, err := s.Repo.Sent(recipientForms(number), before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := s.Repo.Replies(normalized, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ConversationEntry, 0, len(sent)+len(replies))
+	for _, msg := range sent {
+		entries = append(entries, ConversationEntry{
+			ID: msg.ID, Direction: DirectionOutbound, Content: msg.Content, At: *msg.SentAt, CampaignID: msg.CampaignID,
+		})
+	}
+	for _, msg := range replies {
+		entries = append(entries, ConversationEntry{
+			ID: msg.ID, Direction: DirectionInbound, Content: msg.Content, At: msg.ReceivedAt, InReplyTo: msg.InReplyTo,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].At.Equal(entries[j].At) {
+			return entries[i].At.After(entries[j].At)
+		}
+		return bytes.Compare(entries[i].ID[:], entries[j].ID[:]) > 0
+	})
+
+	conversation := &Conversation{Phone: normalized, Entries: entries}
+	if len(entries) > limit {
+		conversation.Entries = entries[:limit]
+		last := conversation.Entries[limit-1]
+		conversation.NextCursor = repository.Position{At: last.At, ID: last.ID}.Cursor()
+	}
+	return conversation, nil
+}
+
+// recipientForms lists how a number may be stored on outbound messages. Message recipients
+// are kept as submitted, so both the given and the normalized form are matched.
+func recipientForms(number string) []string {
+	trimmed := strings.TrimSpace(number)
+	if normalized := phone.Normalize(number); normalized != trimmed {
+		return []string{trimmed, normalized}
+	}
+	return []string{trimmed}
+}
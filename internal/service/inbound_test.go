@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"insider-assessment/internal/service"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockInboundRepository is a mock implementation of repository.InboundRepository
+type MockInboundRepository struct {
+	mock.Mock
+}
+
+func (m *MockInboundRepository) Create(msg *model.InboundMessage) (bool, error) {
+	args := m.Called(msg)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInboundRepository) ByRemoteID(remoteID string) (*model.InboundMessage, error) {
+	args := m.Called(remoteID)
+	msg, _ := args.Get(0).(*model.InboundMessage)
+	return msg, args.Error(1)
+}
+
+func (m *MockInboundRepository) LastSent(recipients []string) (*uuid.UUID, error) {
+	args := m.Called(recipients)
+	id, _ := args.Get(0).(*uuid.UUID)
+	return id, args.Error(1)
+}
+
+func (m *MockInboundRepository) Replies(from string, before *repository.Position, limit int) ([]model.InboundMessage, error) {
+	args := m.Called(from, before, limit)
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *MockInboundRepository) Sent(recipients []string, before *repository.Position, limit int) ([]model.Message, error) {
+	args := m.Called(recipients, before, limit)
+	return args.Get(0).([]model.Message), args.Error(1)
+}
+
+func TestInboundService_RedeliveryIsIgnored(t *testing.T) {
+	repo := new(MockInboundRepository)
+	suppressions := new(MockSuppressionRepository)
+	svc := service.NewInboundService(repo, service.NewSuppressionService(suppressions, "STOP", "START"))
+
+	// the number is matched as submitted and normalized
+	repo.On("LastSent", []string{"+90 555 111 22 33", "+905551112233"}).Return(nil, nil)
+	repo.On("Create", mock.MatchedBy(func(m *model.InboundMessage) bool {
+		return m.From == "+905551112233" && m.RemoteID == "mo-1" && m.InReplyTo == nil
+	})).Return(false, nil)
+	original := &model.InboundMessage{ID: uuid.New(), From: "+905551112233", Content: "STOP", RemoteID: "mo-1"}
+	repo.On("ByRemoteID", "mo-1").Return(original, nil)
+
+	msg, action, err := svc.Receive("+90 555 111 22 33", "STOP", " mo-1 ")
+	require.NoError(t, err)
+	assert.Equal(t, service.KeywordNone, action)
+	assert.Equal(t, original.ID, msg.ID)
+	assert.Equal(t, "+905551112233", msg.From)
+	suppressions.AssertNotCalled(t, "Add", mock.Anything)
+}
+
+func TestInboundService_ConversationPages(t *testing.T) {
+	repo := new(MockInboundRepository)
+	svc := service.NewInboundService(repo, nil)
+
+	now := time.Now()
+	at := func(minutes int) *time.Time {
+		t := now.Add(-time.Duration(minutes) * time.Minute)
+		return &t
+	}
+	// neither side fills a page, but together they do
+	repo.On("Sent", []string{"+905551112233"}, (*repository.Position)(nil), 4).Return([]model.Message{
+		{ID: uuid.New(), Content: "third", SentAt: at(10)},
+		{ID: uuid.New(), Content: "first", SentAt: at(30)},
+	}, nil)
+	repo.On("Replies", "+905551112233", (*repository.Position)(nil), 4).Return([]model.InboundMessage{
+		{ID: uuid.New(), Content: "fourth", ReceivedAt: *at(5)},
+		{ID: uuid.New(), Content: "second", ReceivedAt: *at(20)},
+	}, nil)
+
+	conversation, err := svc.Conversation("+905551112233", "", 3)
+	require.NoError(t, err)
+	require.Len(t, conversation.Entries, 3)
+	assert.Equal(t, "fourth", conversation.Entries[0].Content)
+	assert.Equal(t, "third", conversation.Entries[1].Content)
+	assert.Equal(t, "second", conversation.Entries[2].Content)
+	require.NotEmpty(t, conversation.NextCursor)
+
+	next, err := repository.ParsePosition(conversation.NextCursor)
+	require.NoError(t, err)
+	assert.True(t, next.At.Equal(*at(20)))
+	assert.Equal(t, conversation.Entries[2].ID, next.ID)
+
+	_, err = svc.Conversation("+905551112233", "not-a-cursor", 3)
+	assert.True(t, service.IsValidationError(err))
+}
+
+func TestInboundService_ConversationPagesThroughSharedTimestamp(t *testing.T) {
+	repo := new(MockInboundRepository)
+	svc := service.NewInboundService(repo, nil)
+
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	earlier := at.Add(-time.Minute)
+	sentAt, reply, older := uuid.MustParse("00000000-0000-0000-0000-000000000003"), uuid.MustParse("00000000-0000-0000-0000-000000000002"), uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+	// a message and its reply share a timestamp, and the page ends between them
+	repo.On("Sent", []string{"+905551112233"}, (*repository.Position)(nil), 2).Return([]model.Message{
+		{ID: sentAt, Content: "sale", SentAt: &at},
+		{ID: older, Content: "welcome", SentAt: &earlier},
+	}, nil).Once()
+	repo.On("Replies", "+905551112233", (*repository.Position)(nil), 2).Return([]model.InboundMessage{
+		{ID: reply, Content: "thanks", ReceivedAt: at},
+	}, nil).Once()
+
+	first, err := svc.Conversation("+905551112233", "", 1)
+	require.NoError(t, err)
+	require.Len(t, first.Entries, 1)
+	assert.Equal(t, sentAt, first.Entries[0].ID)
+
+	// the next page continues after that entry, not after its timestamp
+	boundary := mock.MatchedBy(func(p *repository.Position) bool {
+		return p != nil && p.At.Equal(at) && p.ID == sentAt
+	})
+	repo.On("Sent", []string{"+905551112233"}, boundary, 2).Return([]model.Message{
+		{ID: older, Content: "welcome", SentAt: &earlier},
+	}, nil).Once()
+	repo.On("Replies", "+905551112233", boundary, 2).Return([]model.InboundMessage{
+		{ID: reply, Content: "thanks", ReceivedAt: at},
+	}, nil).Once()
+
+	second, err := svc.Conversation("+905551112233", first.NextCursor, 1)
+	require.NoError(t, err)
+	require.Len(t, second.Entries, 1)
+	assert.Equal(t, reply, second.Entries[0].ID)
+	assert.NotEmpty(t, second.NextCursor)
+	repo.AssertExpectations(t)
+}
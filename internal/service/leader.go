@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"insider-assessment/internal/repository"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// LeaderElector keeps competing for the scheduler lock so exactly one replica ticks.
+// The leader re-checks its lock every Interval; followers retry acquiring it at the same pace,
+// so a lost leader is replaced within about one interval.
+type LeaderElector struct {
+	Lock     repository.LeaderLock
+	Identity string
+	Interval time.Duration
+
+	mu     sync.Mutex
+	leader bool
+	since  *time.Time
+	quit   chan struct{}
+	done   chan struct{}
+}
+
+// LeaderStatus describes this instance's role and who currently leads
+type LeaderStatus struct {
+	Instance       string     `json:"instance"`
+	Leader         bool       `json:"leader"`
+	LeaderSince    *time.Time `json:"leader_since,omitempty"`
+	LeaderInstance string     `json:"leader_instance,omitempty"`
+}
+
+func NewLeaderElector(lock repository.LeaderLock, identity string, interval time.Duration) *LeaderElector {
+	return &LeaderElector{Lock: lock, Identity: identity, Interval: interval}
+}
+
+// Run campaigns for leadership in the background until Close is called. The first attempt
+// is made before Run returns, so a scheduler started right after knows whether it leads.
+func (e *LeaderElector) Run() {
+	e.mu.Lock()
+	if e.quit != nil {
+		e.mu.Unlock()
+		return
+	}
+	e.quit = make(chan struct{})
+	e.done = make(chan struct{})
+	quit, done := e.quit, e.done
+	e.mu.Unlock()
+
+	e.campaign()
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(e.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				e.campaign()
+			case <-quit:
+				e.resign()
+				return
+			}
+		}
+	}()
+}
+
+// Close stops campaigning and releases the lock so another replica can take over right away
+func (e *LeaderElector) Close() {
+	e.mu.Lock()
+	quit, done := e.quit, e.done
+	e.mu.Unlock()
+
+	if quit == nil {
+		return
+	}
+	close(quit)
+	<-done
+}
+
+// IsLeader reports whether this instance currently holds the lock
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Status reports this instance's role together with the identity of the current leader
+func (e *LeaderElector) Status(ctx context.Context) LeaderStatus {
+	e.mu.Lock()
+	status := LeaderStatus{Instance: e.Identity, Leader: e.leader, LeaderSince: e.since}
+	e.mu.Unlock()
+
+	holder, err := e.Lock.Holder(ctx)
+	if err != nil {
+		slog.Error("failed to look up scheduler leader", "error", err)
+	}
+	status.LeaderInstance = holder
+	return status
+}
+
+func (e *LeaderElector) campaign() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.Interval)
+	defer cancel()
+
+	if e.IsLeader() {
+		if err := e.Lock.Check(ctx); err != nil {
+			slog.Error("lost scheduler leadership", "instance", e.Identity, "error", err)
+			e.setLeader(false)
+		}
+		return
+	}
+
+	acquired, err := e.Lock.TryAcquire(ctx)
+	if err != nil {
+		slog.Error("failed to acquire scheduler leadership", "instance", e.Identity, "error", err)
+		return
+	}
+	if acquired {
+		slog.Info("became scheduler leader", "instance", e.Identity)
+		e.setLeader(true)
+	}
+}
+
+func (e *LeaderElector) resign() {
+	if !e.IsLeader() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.Interval)
+	defer cancel()
+	if err := e.Lock.Release(ctx); err != nil {
+		slog.Error("failed to release scheduler leadership", "instance", e.Identity, "error", err)
+	}
+	e.setLeader(false)
+	slog.Info("resigned scheduler leadership", "instance", e.Identity)
+}
+
+func (e *LeaderElector) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.leader = leader
+	if leader {
+		now := time.Now()
+		e.since = &now
+	} else {
+		e.since = nil
+	}
+}
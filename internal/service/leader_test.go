@@ -0,0 +1,71 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"insider-assessment/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLeaderLock is a mock implementation of repository.LeaderLock
+type MockLeaderLock struct {
+	mock.Mock
+}
+
+func (m *MockLeaderLock) TryAcquire(ctx context.Context) (bool, error) {
+	args := m.Called()
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLeaderLock) Check(ctx context.Context) error {
+	return m.Called().Error(0)
+}
+
+func (m *MockLeaderLock) Release(ctx context.Context) error {
+	return m.Called().Error(0)
+}
+
+func (m *MockLeaderLock) Holder(ctx context.Context) (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func TestLeaderElector_AcquiresAndLosesLeadership(t *testing.T) {
+	lock := new(MockLeaderLock)
+	lock.On("TryAcquire").Return(true, nil).Once()
+	lock.On("Check").Return(errors.New("connection reset")).Once()
+	lock.On("TryAcquire").Return(false, nil)
+	lock.On("Holder").Return("replica-b", nil)
+
+	elector := service.NewLeaderElector(lock, "replica-a", 20*time.Millisecond)
+	elector.Run()
+	defer elector.Close()
+
+	assert.Eventually(t, elector.IsLeader, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return !elector.IsLeader() }, time.Second, 5*time.Millisecond)
+
+	status := elector.Status(context.Background())
+	assert.Equal(t, "replica-a", status.Instance)
+	assert.False(t, status.Leader)
+	assert.Equal(t, "replica-b", status.LeaderInstance)
+}
+
+func TestLeaderElector_CloseReleasesLock(t *testing.T) {
+	lock := new(MockLeaderLock)
+	lock.On("TryAcquire").Return(true, nil)
+	lock.On("Check").Return(nil)
+	lock.On("Release").Return(nil).Once()
+
+	elector := service.NewLeaderElector(lock, "replica-a", 20*time.Millisecond)
+	elector.Run()
+	// the first attempt is made before Run returns
+	assert.True(t, elector.IsLeader())
+
+	elector.Close()
+	assert.False(t, elector.IsLeader())
+	lock.AssertCalled(t, "Release")
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// OutboxRelay publishes the lifecycle events the repositories store in the outbox. An event
+// stays in the outbox until the publisher reached Redis and every interested subscription,
+// so delivery is at least once: a failed attempt is repeated for the targets that missed it,
+// an interrupted one for all of them, and consumers deduplicate on the event ID.
+type OutboxRelay struct {
+	Outbox      repository.OutboxRepository
+	Publisher   *EventPublisher
+	Interval    time.Duration // between polls while the outbox is drained
+	BatchSize   int
+	MaxAttempts int           // an event still failing after this many attempts is given up
+	Backoff     time.Duration // before the first retry, doubled for every further one
+	Retention   time.Duration // delivered and given-up events are kept this long
+
+	mu   sync.Mutex
+	quit chan struct{}
+	done chan struct{}
+}
+
+// maxOutboxBackoff caps the delay between two attempts at one event
+const maxOutboxBackoff = time.Hour
+
+// minOutboxLease is the shortest time a leased batch is reserved for the relay that took it
+const minOutboxLease = 5 * time.Minute
+
+// outboxLeaseMargin is added to the time a batch may take to deliver when it is leased
+const outboxLeaseMargin = time.Minute
+
+func NewOutboxRelay(outbox repository.OutboxRepository, publisher *EventPublisher, interval time.Duration, maxAttempts int, backoff, retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		Outbox:      outbox,
+		Publisher:   publisher,
+		Interval:    interval,
+		BatchSize:   100,
+		MaxAttempts: maxAttempts,
+		Backoff:     backoff,
+		Retention:   retention,
+	}
+}
+
+// Run relays events in the background until Close is called. A full batch is followed by
+// the next one right away, so a backlog drains without waiting for the interval.
+func (r *OutboxRelay) Run() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.quit != nil {
+		return
+	}
+	r.quit = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+		purge := time.NewTicker(time.Hour)
+		defer purge.Stop()
+
+		r.purge()
+		for {
+			relayed, err := r.RelayOnce(context.Background())
+			if err != nil {
+				slog.Error("outbox relay failed", "error", err)
+			}
+			if err == nil && relayed == r.BatchSize {
+				select {
+				case <-r.quit:
+					return
+				default:
+					continue
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-purge.C:
+				r.purge()
+			case <-r.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the relay after the batch in flight
+func (r *OutboxRelay) Close() {
+	r.mu.Lock()
+	quit, done := r.quit, r.done
+	r.mu.Unlock()
+
+	if quit == nil {
+		return
+	}
+	close(quit)
+	<-done
+}
+
+// RelayOnce publishes one batch of due events and returns how many were delivered. The batch
+// is leased in a short transaction and delivered outside of it, so no row lock is held during
+// the HTTP calls; each outcome is stored as soon as the event is done.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	subs, err := r.Publisher.LoadSubscriptions()
+	if err != nil {
+		return 0, err
+	}
+
+	due, err := r.Outbox.Lease(r.BatchSize, r.MaxAttempts, r.lease())
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, row := range due {
+		reached, err := r.Publisher.Deliver(ctx, row.Event, subs, row.DeliveredTo)
+		targets := append(row.DeliveredTo, reached...)
+		if err == nil {
+			err := r.Outbox.MarkDelivered(row.ID, row.NextAttemptAt, targets)
+			if errors.Is(err, repository.ErrConflict) {
+				r.lostLease(row)
+				continue
+			}
+			if err != nil {
+				return delivered, err
+			}
+			delivered++
+			continue
+		}
+
+		if row.Attempts >= r.MaxAttempts {
+			slog.Error("giving up on event", "event_id", row.EventID, "type", row.Type,
+				"message_id", row.Event.Data.MessageID, "attempts", row.Attempts, "error", err)
+		} else {
+			slog.Warn("event delivery failed", "event_id", row.EventID, "type", row.Type,
+				"attempt", row.Attempts, "error", err)
+		}
+		err = r.Outbox.MarkFailed(row.ID, row.NextAttemptAt, targets, err.Error(), time.Now().Add(r.backoff(row.Attempts)))
+		if errors.Is(err, repository.ErrConflict) {
+			r.lostLease(row)
+		} else if err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// lease returns how long a batch is reserved: long enough for every event of a full batch
+// to wait out the webhook timeout, as subscriptions of one event are posted in parallel
+func (r *OutboxRelay) lease() time.Duration {
+	timeout := r.Publisher.Client.Timeout
+	if timeout <= 0 {
+		return minOutboxLease
+	}
+	return max(time.Duration(r.BatchSize)*timeout+outboxLeaseMargin, minOutboxLease)
+}
+
+// lostLease reports an event whose lease ran out while it was delivered. Another relay took
+// it over, so its outcome is left to that relay.
+func (r *OutboxRelay) lostLease(row model.OutboxEvent) {
+	slog.Warn("outbox lease ran out during delivery, leaving the event to the relay that took it over",
+		"event_id", row.EventID, "type", row.Type, "attempt", row.Attempts)
+}
+
+// backoff returns the delay after the given failed attempt
+func (r *OutboxRelay) backoff(attempt int) time.Duration {
+	delay := r.Backoff
+	for i := 1; i < attempt && delay < maxOutboxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxOutboxBackoff)
+}
+
+func (r *OutboxRelay) purge() {
+	if r.Retention <= 0 {
+		return
+	}
+	purged, err := r.Outbox.Purge(time.Now().Add(-r.Retention), r.MaxAttempts)
+	if err != nil {
+		slog.Error("failed to purge outbox events", "error", err)
+		return
+	}
+	if purged > 0 {
+		slog.Info("purged delivered and given-up outbox events", "count", purged)
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Queue backends, selected with QUEUE_BACKEND
+const (
+	QueueDB    = "db"
+	QueueRedis = "redis"
+)
+
+// Queue decides which pending messages the worker claims next. Postgres stays the system
+// of record: a claim is always the PENDING -> PROCESSING transition in the database, so a
+// queue entry for a message that was cancelled or already sent is simply dropped.
+type Queue interface {
+	// Enqueue announces messages committed as PENDING. A failure only delays them.
+	Enqueue(ctx context.Context, ids ...uuid.UUID) error
+	// Claim moves up to limit messages to PROCESSING and returns them
+	Claim(ctx context.Context, limit int) ([]model.Message, error)
+	// Ack confirms that claimed messages were handled
+	Ack(ctx context.Context, ids ...uuid.UUID) error
+}
+
+// dbQueue polls the messages table; it needs no bookkeeping of its own
+type dbQueue struct {
+	repo repository.MessageRepository
+}
+
+// NewDBQueue returns the default queue, which claims the oldest claimable rows
+func NewDBQueue(repo repository.MessageRepository) Queue {
+	return &dbQueue{repo: repo}
+}
+
+func (q *dbQueue) Enqueue(ctx context.Context, ids ...uuid.UUID) error {
+	return nil
+}
+
+func (q *dbQueue) Claim(ctx context.Context, limit int) ([]model.Message, error) {
+	return q.repo.WithContext(ctx).ClaimPending(limit)
+}
+
+func (q *dbQueue) Ack(ctx context.Context, ids ...uuid.UUID) error {
+	return nil
+}
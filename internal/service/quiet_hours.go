@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"insider-assessment/internal/model"
+	"insider-assessment/pkg/cron"
+	"insider-assessment/pkg/phone"
+	"strings"
+	"time"
+)
+
+// QuietHoursPolicy holds, per message category, the hours a message must not reach its
+// recipient. The hours are local to the recipient, so one policy covers every timezone.
+type QuietHoursPolicy struct {
+	windows  map[string]*cron.Window
+	fallback *time.Location
+}
+
+// ParseQuietHours reads a policy such as "marketing=21:00-09:00;transactional=Mon-Fri 23:00-07:00".
+// Categories without an entry are never held back. defaultTimezone applies to recipients
+// whose timezone is neither set on the message nor inferable from their number.
+func ParseQuietHours(spec, defaultTimezone string) (*QuietHoursPolicy, error) {
+	fallback, err := time.LoadLocation(defaultTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("quiet hours: unknown default timezone %q", defaultTimezone)
+	}
+
+	p := &QuietHoursPolicy{windows: map[string]*cron.Window{}, fallback: fallback}
+	for _, entry := range strings.Split(spec, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		category, expr, ok := strings.Cut(entry, "=")
+		category = strings.ToLower(strings.TrimSpace(category))
+		if !ok || !validCategory(category) {
+			return nil, fmt.Errorf("quiet hours: expected \"category=[days] HH:MM-HH:MM\", got %q", entry)
+		}
+		window, err := cron.ParseWindow(expr)
+		if err != nil {
+			return nil, fmt.Errorf("quiet hours for %s: %w", category, err)
+		}
+		if window.NextClose(time.Now()).IsZero() {
+			return nil, fmt.Errorf("quiet hours for %s never end", category)
+		}
+		p.windows[category] = window
+	}
+	return p, nil
+}
+
+// Empty reports whether no category has quiet hours
+func (p *QuietHoursPolicy) Empty() bool {
+	return p == nil || len(p.windows) == 0
+}
+
+// NextEligible returns now if msg may be delivered now, otherwise the end of the
+// recipient's quiet hours
+func (p *QuietHoursPolicy) NextEligible(msg model.Message, now time.Time) time.Time {
+	if p.Empty() {
+		return now
+	}
+	window, ok := p.windows[msg.Category]
+	if !ok {
+		return now
+	}
+	return window.In(p.Location(msg)).NextClose(now)
+}
+
+// Location returns the recipient's timezone: the one stored on the message, else the one
+// inferred from the number's country code, else the policy default
+func (p *QuietHoursPolicy) Location(msg model.Message) *time.Location {
+	for _, name := range []string{msg.Timezone, phone.Timezone(msg.To)} {
+		if name == "" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return p.fallback
+}
+
+// ValidateRecipient checks the optional category and timezone of a new message
+func ValidateRecipient(category, timezone string) error {
+	var problems []string
+	for _, err := range []error{checkCategory(category), checkTimezone(timezone)} {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func checkCategory(category string) error {
+	if category != "" && !validCategory(model.NormalizeCategory(category)) {
+		return fmt.Errorf("unknown category %q (use %s or %s)", category, model.CategoryTransactional, model.CategoryMarketing)
+	}
+	return nil
+}
+
+func checkTimezone(timezone string) error {
+	if timezone == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", timezone)
+	}
+	return nil
+}
+
+func validCategory(category string) bool {
+	return category == model.CategoryTransactional || category == model.CategoryMarketing
+}
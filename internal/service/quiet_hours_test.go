@@ -0,0 +1,65 @@
+package service_test
+
+import (
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHoursPolicy_NextEligible(t *testing.T) {
+	policy, err := service.ParseQuietHours("marketing=21:00-09:00", "America/New_York")
+	require.NoError(t, err)
+
+	istanbul, _ := time.LoadLocation("Europe/Istanbul")
+	now := time.Date(2024, 3, 4, 20, 30, 0, 0, time.UTC) // 23:30 in Istanbul, 15:30 in New York
+
+	// timezone inferred from +90
+	turkish := model.Message{To: "+905551112233", Category: model.CategoryMarketing}
+	assert.True(t, time.Date(2024, 3, 5, 9, 0, 0, 0, istanbul).Equal(policy.NextEligible(turkish, now)))
+
+	// transactional messages have no quiet hours here
+	turkish.Category = model.CategoryTransactional
+	assert.Equal(t, now, policy.NextEligible(turkish, now))
+
+	// an explicit timezone wins over the country code
+	london := model.Message{To: "+905551112233", Category: model.CategoryMarketing, Timezone: "Europe/London"}
+	assert.Equal(t, now, policy.NextEligible(london, now))
+
+	// +1 spans several timezones, so the default applies
+	american := model.Message{To: "+12025550123", Category: model.CategoryMarketing}
+	assert.Equal(t, "America/New_York", policy.Location(american).String())
+	assert.Equal(t, now, policy.NextEligible(american, now))
+}
+
+func TestParseQuietHours_Invalid(t *testing.T) {
+	for _, spec := range []string{"21:00-09:00", "promo=21:00-09:00", "marketing=21:00", "marketing=00:00-24:00"} {
+		_, err := service.ParseQuietHours(spec, "UTC")
+		assert.Error(t, err, spec)
+	}
+	_, err := service.ParseQuietHours("", "Mars/Olympus")
+	assert.Error(t, err)
+
+	policy, err := service.ParseQuietHours(" ", "UTC")
+	require.NoError(t, err)
+	assert.True(t, policy.Empty())
+}
+
+func TestNormalizeCategory(t *testing.T) {
+	// without a category the stricter marketing quiet hours apply
+	assert.Equal(t, model.CategoryMarketing, model.NormalizeCategory(""))
+	assert.Equal(t, model.CategoryTransactional, model.NormalizeCategory(" Transactional"))
+}
+
+func TestValidateRecipient(t *testing.T) {
+	assert.NoError(t, service.ValidateRecipient("", ""))
+	assert.NoError(t, service.ValidateRecipient(model.CategoryMarketing, "Europe/Istanbul"))
+	assert.NoError(t, service.ValidateRecipient(" Transactional ", ""))
+
+	err := service.ValidateRecipient("promo", "Nowhere/City")
+	require.True(t, service.IsValidationError(err))
+	assert.Len(t, err.(*service.ValidationError).Problems, 2)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// reapBatchSize is how many expired claims one pass releases at most
+const reapBatchSize = 500
+
+// LeaseReaper returns messages that stayed in PROCESSING longer than the claim lease to
+// PENDING, so they are sent after all when a worker crashed mid-batch or failed to store an
+// outcome. The lease must outlast a batch, webhook timeout included, or a message still in
+// flight is released and may be sent twice. Every replica may run it.
+type LeaseReaper struct {
+	Repo     repository.MessageRepository
+	Lease    time.Duration
+	Interval time.Duration
+	Queue    Queue        // optional; released messages are announced to it again
+	Updates  *Broadcaster // optional
+
+	mu   sync.Mutex
+	quit chan struct{}
+	done chan struct{}
+}
+
+// NewLeaseReaper checks for expired claims every lease/2, at least once a minute
+func NewLeaseReaper(repo repository.MessageRepository, lease time.Duration) *LeaseReaper {
+	return &LeaseReaper{
+		Repo:     repo,
+		Lease:    lease,
+		Interval: max(min(lease/2, time.Minute), time.Second),
+	}
+}
+
+// Run releases expired claims in the background until Close is called
+func (r *LeaseReaper) Run() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.quit != nil || r.Lease <= 0 {
+		return
+	}
+	r.quit = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := r.ReapOnce(context.Background()); err != nil {
+				slog.Error("failed to release expired claims", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-r.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the reaper after the pass in flight
+func (r *LeaseReaper) Close() {
+	r.mu.Lock()
+	quit, done := r.quit, r.done
+	r.mu.Unlock()
+
+	if quit == nil {
+		return
+	}
+	close(quit)
+	<-done
+}
+
+// ReapOnce releases the messages whose claim expired and returns how many there were
+func (r *LeaseReaper) ReapOnce(ctx context.Context) (int, error) {
+	released, err := r.Repo.WithContext(ctx).ReleaseExpired(time.Now().Add(-r.Lease), reapBatchSize)
+	if err != nil || len(released) == 0 {
+		return 0, err
+	}
+	slog.Warn("released messages with an expired claim", "count", len(released), "lease", r.Lease)
+
+	ids := make([]uuid.UUID, len(released))
+	for i, msg := range released {
+		ids[i] = msg.ID
+		r.Updates.Publish(StatusUpdate{
+			MessageID:  msg.ID,
+			To:         msg.To,
+			CampaignID: msg.CampaignID,
+			From:       model.StatusProcessing,
+			Status:     model.StatusPending,
+		})
+	}
+	if r.Queue != nil {
+		if err := r.Queue.Enqueue(ctx, ids...); err != nil {
+			slog.Warn("failed to enqueue released messages", "count", len(ids), "error", err)
+		}
+	}
+	return len(released), nil
+}
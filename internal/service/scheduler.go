@@ -1,74 +1,506 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"insider-assessment/internal/config"
+	"insider-assessment/internal/repository"
+	"insider-assessment/pkg/cron"
 	"log/slog"
 	"sync"
 	"time"
 )
 
-// Scheduler handles the background ticker.
+var (
+	ErrAlreadyRunning = errors.New("scheduler is already running")
+	ErrNotRunning     = errors.New("scheduler is not running")
+	ErrCycleRunning   = errors.New("a processing cycle is already in progress")
+	ErrNotLeader      = errors.New("this replica is not the scheduler leader")
+	ErrOutsideWindow  = errors.New("outside the send window")
+	ErrStateUnknown   = errors.New("scheduler state can't be read")
+)
+
+// MaxBatchSize caps how many messages a single tick may claim
+const MaxBatchSize = 1000
+
+// Scheduler handles the background timer.
+//
+// The timer loop runs for the lifetime of the process; Start and Stop change the desired
+// state, which every tick checks first. With a State repository that desired state is shared
+// by all replicas and survives restarts, without one it only lives in memory. With an Elector
+// only the leader replica sends; the others keep ticking idle so they can take over.
+//
+// Ticks fire every interval, or at the times of a cron schedule when one is set. A send
+// window additionally restricts ticks to e.g. business hours; outside of it messages stay PENDING.
 type Scheduler struct {
 	Sender  *WorkerService
 	Config  *config.Config
-	ticker  *time.Ticker
+	State   repository.SchedulerStateRepository
+	Elector *LeaderElector // nil means this instance always ticks
+	looping bool
+	wake    chan struct{}
 	quit    chan struct{}
 	running bool
 	mu      sync.Mutex
+
+	// runtime settings, seeded from Config and changed through Configure
+	interval  time.Duration
+	batchSize int
+	schedule  *cron.Schedule // replaces interval when set
+	window    *cron.Window
+
+	lastTick   *time.Time
+	lastResult *BatchResult
+	planned    time.Time // when the loop's timer fires next, zero if never
+	changedBy  string
+	changedAt  *time.Time
+
+	// cycle serializes batches so a slow tick never overlaps the next one
+	cycle sync.Mutex
+}
+
+// SchedulerSettings changes the scheduler at runtime. Nil fields keep their value;
+// an empty Schedule or Window removes it.
+type SchedulerSettings struct {
+	Interval  *time.Duration
+	BatchSize *int
+	Schedule  *string
+	Window    *string
+}
+
+// SchedulerStatus is a snapshot of the scheduler's state
+type SchedulerStatus struct {
+	Running    bool         `json:"running"`
+	Interval   string       `json:"interval"`
+	Schedule   string       `json:"schedule,omitempty"`
+	Window     string       `json:"window,omitempty"`
+	InWindow   bool         `json:"in_window"`
+	BatchSize  int          `json:"batch_size"`
+	LastTick   *time.Time   `json:"last_tick,omitempty"`
+	LastResult *BatchResult `json:"last_result,omitempty"`
+	NextTick   *time.Time   `json:"next_tick,omitempty"`
+	ChangedBy  string       `json:"changed_by,omitempty"` // who last started or stopped the scheduler
+	ChangedAt  *time.Time   `json:"changed_at,omitempty"`
+
+	Leadership *LeaderStatus `json:"leadership,omitempty"`
 }
 
-func NewScheduler(sender *WorkerService, cfg *config.Config) *Scheduler {
-	return &Scheduler{
-		Sender: sender,
-		Config: cfg,
-		quit:   make(chan struct{}),
+// NewScheduler seeds the settings from cfg. An invalid WorkerSchedule or SendWindow is an error
+// rather than a silent fallback to sending around the clock.
+func NewScheduler(sender *WorkerService, cfg *config.Config) (*Scheduler, error) {
+	s := &Scheduler{
+		Sender:    sender,
+		Config:    cfg,
+		interval:  cfg.WorkerInterval,
+		batchSize: cfg.WorkerBatchSize,
+		wake:      make(chan struct{}, 1),
+	}
+	err := s.Configure(SchedulerSettings{Schedule: &cfg.WorkerSchedule, Window: &cfg.SendWindow})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run starts the timer loop and runs a first tick right away. Whether ticks send anything
+// depends on the desired state, so an instance started while the cluster is stopped stays idle.
+func (s *Scheduler) Run() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.State == nil {
+		s.running = true
 	}
+	s.startLoop()
 }
 
-// Start initiates the ticker if it's not already running.
-func (s *Scheduler) Start() {
+// Shutdown ends the timer loop. Unlike Stop it doesn't change the desired state.
+func (s *Scheduler) Shutdown() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.running {
-		slog.Warn("scheduler is already running.")
+	if !s.looping {
 		return
 	}
+	close(s.quit)
+	s.looping = false
+}
+
+// Start resumes sending. It returns ErrAlreadyRunning if the scheduler isn't stopped.
+func (s *Scheduler) Start(actor string) error {
+	if err := s.setDesired(true, actor); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slog.Info("starting scheduler", "interval", s.interval, "schedule", s.scheduleExpr(), "window", s.windowExpr(),
+		"batch_size", s.batchSize, "actor", actor)
+	if !s.startLoop() {
+		// run on start
+		s.reschedule()
+		go s.tick()
+	}
+	return nil
+}
+
+// Stop pauses sending. It returns ErrNotRunning if the scheduler already is stopped.
+// A batch that is in flight finishes, but no further batch is claimed.
+func (s *Scheduler) Stop(actor string) error {
+	if err := s.setDesired(false, actor); err != nil {
+		return err
+	}
+
+	slog.Info("Stopping Scheduler...", "actor", actor)
+	return nil
+}
 
-	slog.Info("starting scheduler", "interval", s.Config.WorkerInterval)
+// RunNow runs one batch right away and returns its result. size overrides the configured
+// batch size when positive. It is held to the same checks as a tick: it returns ErrNotRunning
+// while stopped, ErrNotLeader on a follower, ErrOutsideWindow outside the send window and
+// ErrStateUnknown when the desired state can't be read. It returns ErrCycleRunning instead of
+// waiting when a batch is in progress on this instance.
+func (s *Scheduler) RunNow(size int, actor string) (BatchResult, error) {
+	if size < 0 || size > MaxBatchSize {
+		return BatchResult{}, &ValidationError{Problems: []string{"batch_size must be between 1 and 1000"}}
+	}
+	if !s.cycle.TryLock() {
+		return BatchResult{}, ErrCycleRunning
+	}
+	defer s.cycle.Unlock()
+
+	now := time.Now()
+	if err := s.admit(now); err != nil {
+		return BatchResult{}, err
+	}
 
-	s.ticker = time.NewTicker(s.Config.WorkerInterval)
-	s.running = true
+	s.mu.Lock()
+	if size == 0 {
+		size = s.batchSize
+	}
+	s.lastTick = &now
+	s.mu.Unlock()
+
+	slog.Info("running a batch on demand", "batch_size", size, "actor", actor)
+	result := s.Sender.ProcessBatch(size)
+
+	s.mu.Lock()
+	s.lastResult = &result
+	s.mu.Unlock()
+	return result, nil
+}
+
+// Running reports whether the scheduler sends messages, as of the last check of the desired state
+func (s *Scheduler) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// Configure changes the settings without a restart. The next tick is re-planned right away.
+func (s *Scheduler) Configure(settings SchedulerSettings) error {
+	var problems []string
+	if settings.Interval != nil && *settings.Interval < time.Second {
+		problems = append(problems, "interval must be at least 1s")
+	}
+	if settings.BatchSize != nil && (*settings.BatchSize < 1 || *settings.BatchSize > MaxBatchSize) {
+		problems = append(problems, "batch_size must be between 1 and 1000")
+	}
+	var schedule *cron.Schedule
+	if settings.Schedule != nil && *settings.Schedule != "" {
+		var err error
+		if schedule, err = cron.Parse(*settings.Schedule); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	var window *cron.Window
+	if settings.Window != nil && *settings.Window != "" {
+		var err error
+		if window, err = cron.ParseWindow(*settings.Window); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if settings.BatchSize != nil {
+		s.batchSize = *settings.BatchSize
+	}
+	if settings.Interval != nil {
+		s.interval = *settings.Interval
+	}
+	if settings.Schedule != nil {
+		s.schedule = schedule
+	}
+	if settings.Window != nil {
+		s.window = window
+	}
+	s.reschedule()
+
+	slog.Info("scheduler configured", "interval", s.interval, "schedule", s.scheduleExpr(), "window", s.windowExpr(),
+		"batch_size", s.batchSize)
+	return nil
+}
+
+// Status returns the current settings together with the outcome of the last tick.
+// The running state is read fresh so a stop issued on another replica shows up immediately.
+func (s *Scheduler) Status(ctx context.Context) SchedulerStatus {
+	if _, err := s.refresh(); err != nil {
+		slog.Error("failed to read scheduler state", "error", err)
+	}
+
+	var leadership *LeaderStatus
+	if s.Elector != nil {
+		status := s.Elector.Status(ctx)
+		leadership = &status
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := SchedulerStatus{
+		Running:    s.running,
+		Interval:   s.interval.String(),
+		InWindow:   s.window == nil || s.window.Contains(time.Now()),
+		BatchSize:  s.batchSize,
+		LastTick:   s.lastTick,
+		LastResult: s.lastResult,
+		Schedule:   s.scheduleExpr(),
+		Window:     s.windowExpr(),
+		ChangedBy:  s.changedBy,
+		ChangedAt:  s.changedAt,
+		Leadership: leadership,
+	}
+	if s.running && s.looping && !s.planned.IsZero() {
+		next := s.planned
+		status.NextTick = &next
+	}
+	return status
+}
+
+// scheduleExpr and windowExpr return the configured expressions, "" when unset.
+// They must be called with mu held.
+func (s *Scheduler) scheduleExpr() string {
+	if s.schedule == nil {
+		return ""
+	}
+	return s.schedule.String()
+}
+
+func (s *Scheduler) windowExpr() string {
+	if s.window == nil {
+		return ""
+	}
+	return s.window.String()
+}
+
+// startLoop starts the timer goroutine unless it runs already and reports whether it did.
+// It must be called with mu held.
+func (s *Scheduler) startLoop() bool {
+	if s.looping {
+		return false
+	}
+	s.looping = true
 	s.quit = make(chan struct{})
+	s.planned = s.plan(time.Now())
 
-	// run on start
-	go s.Sender.ProcessMessages()
+	go s.tick()
 
-	go func() {
+	go func(quit chan struct{}) {
 		for {
+			s.mu.Lock()
+			next := s.planned
+			s.mu.Unlock()
+
+			var fire <-chan time.Time
+			var timer *time.Timer
+			if !next.IsZero() {
+				timer = time.NewTimer(time.Until(next))
+				fire = timer.C
+			}
+
 			select {
-			case <-s.ticker.C:
-				s.Sender.ProcessMessages()
-			case <-s.quit:
-				s.ticker.Stop()
+			case <-fire:
+				s.tick()
+				s.mu.Lock()
+				// keep the cadence of the planned times unless the batch overran the next one
+				if s.planned = s.plan(next); !s.planned.IsZero() && s.planned.Before(time.Now()) {
+					s.planned = s.plan(time.Now())
+				}
+				s.mu.Unlock()
+			case <-s.wake:
+				// settings changed, planned was updated by reschedule
+			case <-quit:
+				if timer != nil {
+					timer.Stop()
+				}
 				slog.Info("Scheduler stopped.")
 				return
 			}
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}(s.quit)
+
+	return true
+}
+
+// plan returns the next tick after from, skipping ticks outside the send window.
+// The zero time means no tick is due (a schedule or window that never matches).
+// It must be called with mu held.
+func (s *Scheduler) plan(from time.Time) time.Time {
+	next := s.nextAfter(from)
+	if s.window == nil {
+		return next
+	}
+
+	// bounded so a schedule that never meets the window ends
+	for i := 0; i < 1000 && !next.IsZero(); i++ {
+		if s.window.Contains(next) {
+			return next
+		}
+		open := s.window.NextOpen(next)
+		if open.IsZero() || s.schedule == nil {
+			return open
+		}
+		next = s.schedule.Next(open.Add(-time.Minute))
+	}
+	return time.Time{}
+}
+
+func (s *Scheduler) nextAfter(from time.Time) time.Time {
+	if s.schedule == nil {
+		return from.Add(s.interval)
+	}
+	if s.window != nil {
+		// evaluate the schedule in the window's timezone, "0 9 * * *" means 09:00 there
+		from = from.In(s.window.Location())
+	}
+	return s.schedule.Next(from)
+}
+
+// reschedule re-plans the next tick and wakes the loop so it uses it. It must be called with mu held.
+func (s *Scheduler) reschedule() {
+	s.planned = s.plan(time.Now())
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// tick runs one batch with the current batch size and records its outcome.
+// It does nothing while the desired state is stopped, or when that state can't be read:
+// an emergency stop must not be overridden by a database hiccup.
+func (s *Scheduler) tick() {
+	s.cycle.Lock()
+	defer s.cycle.Unlock()
+
+	now := time.Now()
+	if err := s.admit(now); err != nil {
+		if errors.Is(err, ErrStateUnknown) {
+			slog.Error("failed to read scheduler state, skipping tick", "error", err)
+		} else {
+			slog.Info("skipping tick", "reason", err)
 		}
-	}()
+		return
+	}
+
+	s.mu.Lock()
+	size := s.batchSize
+	s.lastTick = &now
+	s.mu.Unlock()
+
+	result := s.Sender.ProcessBatch(size)
+
+	s.mu.Lock()
+	s.lastResult = &result
+	s.mu.Unlock()
 }
 
-// Stop stops the ticker.
-func (s *Scheduler) Stop() {
+// admit checks whether this instance may send a batch at now: the desired state is running,
+// it is the leader and now is within the send window. A desired state that can't be read
+// refuses the batch, so an emergency stop is never overridden by a database hiccup.
+func (s *Scheduler) admit(now time.Time) error {
+	running, err := s.refresh()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStateUnknown, err)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.running {
-		slog.Warn("Scheduler is not running.")
-		return
+	if !running {
+		return ErrNotRunning
+	}
+	if s.Elector != nil && !s.Elector.IsLeader() {
+		return fmt.Errorf("%w (instance %s)", ErrNotLeader, s.Elector.Identity)
+	}
+	if s.window != nil && !s.window.Contains(now) {
+		return fmt.Errorf("%w %s", ErrOutsideWindow, s.windowExpr())
 	}
+	return nil
+}
 
-	slog.Info("Stopping Scheduler...")
-	close(s.quit)
-	s.running = false
+// refresh reads the desired state from the State repository and caches it
+func (s *Scheduler) refresh() (bool, error) {
+	if s.State == nil {
+		return s.Running(), nil
+	}
+
+	state, err := s.State.Get()
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apply(!state.Paused, state.UpdatedBy, state.UpdatedAt)
+	return s.running, nil
+}
+
+// setDesired changes the desired state, persisting it when a State repository is set
+func (s *Scheduler) setDesired(running bool, actor string) error {
+	conflict := ErrNotRunning
+	if running {
+		conflict = ErrAlreadyRunning
+	}
+
+	if s.State == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.running == running {
+			return conflict
+		}
+		s.apply(running, actor, time.Now())
+		return nil
+	}
+
+	state, err := s.State.SetPaused(!running, actor)
+	if errors.Is(err, repository.ErrConflict) {
+		return conflict
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apply(!state.Paused, state.UpdatedBy, state.UpdatedAt)
+	return nil
+}
+
+// apply caches the desired state. It must be called with mu held.
+func (s *Scheduler) apply(running bool, changedBy string, changedAt time.Time) {
+	s.running = running
+	s.changedBy = changedBy
+	if !changedAt.IsZero() {
+		s.changedAt = &changedAt
+	}
 }
@@ -0,0 +1,226 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"insider-assessment/internal/config"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"insider-assessment/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSchedulerStateRepository is a mock implementation of repository.SchedulerStateRepository
+type MockSchedulerStateRepository struct {
+	mock.Mock
+}
+
+func (m *MockSchedulerStateRepository) Get() (*model.SchedulerState, error) {
+	args := m.Called()
+	if state, ok := args.Get(0).(*model.SchedulerState); ok {
+		return state, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSchedulerStateRepository) SetPaused(paused bool, actor string) (*model.SchedulerState, error) {
+	args := m.Called(paused, actor)
+	if state, ok := args.Get(0).(*model.SchedulerState); ok {
+		return state, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestScheduler_PersistedStopKeepsInstanceIdle(t *testing.T) {
+	repo := new(MockRepository)
+	state := new(MockSchedulerStateRepository)
+	state.On("Get").Return(&model.SchedulerState{Paused: true, UpdatedBy: "ops"}, nil)
+	state.On("SetPaused", true, "api").Return(nil, repository.ErrConflict)
+
+	cfg := &config.Config{WorkerInterval: time.Minute, WorkerBatchSize: 2}
+	scheduler, err := service.NewScheduler(service.NewWorkerService(repo, nil, cfg), cfg)
+	assert.NoError(t, err)
+	scheduler.State = state
+
+	scheduler.Run()
+	defer scheduler.Shutdown()
+	time.Sleep(50 * time.Millisecond)
+
+	status := scheduler.Status(context.Background())
+	assert.False(t, status.Running)
+	assert.Equal(t, "ops", status.ChangedBy)
+	assert.ErrorIs(t, scheduler.Stop("api"), service.ErrNotRunning)
+	repo.AssertNotCalled(t, "ClaimPending", mock.Anything)
+}
+
+func TestScheduler_StartPersistsAndTicks(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("ClaimPending", 2).Return([]model.Message{}, nil)
+	state := new(MockSchedulerStateRepository)
+	state.On("SetPaused", false, "api").Return(&model.SchedulerState{UpdatedBy: "api", UpdatedAt: time.Now()}, nil)
+	state.On("Get").Return(&model.SchedulerState{UpdatedBy: "api"}, nil)
+
+	cfg := &config.Config{WorkerInterval: time.Minute, WorkerBatchSize: 2}
+	scheduler, err := service.NewScheduler(service.NewWorkerService(repo, nil, cfg), cfg)
+	assert.NoError(t, err)
+	scheduler.State = state
+
+	assert.NoError(t, scheduler.Start("api"))
+	defer scheduler.Shutdown()
+	time.Sleep(50 * time.Millisecond)
+
+	assert.True(t, scheduler.Running())
+	state.AssertExpectations(t)
+	repo.AssertExpectations(t)
+}
+
+func TestScheduler_FollowerDoesNotSend(t *testing.T) {
+	repo := new(MockRepository)
+	lock := new(MockLeaderLock)
+	lock.On("TryAcquire").Return(false, nil)
+	lock.On("Holder").Return("replica-b", nil)
+
+	cfg := &config.Config{WorkerInterval: time.Minute, WorkerBatchSize: 2}
+	scheduler, err := service.NewScheduler(service.NewWorkerService(repo, nil, cfg), cfg)
+	assert.NoError(t, err)
+	scheduler.Elector = service.NewLeaderElector(lock, "replica-a", time.Minute)
+	scheduler.Elector.Run()
+	defer scheduler.Elector.Close()
+
+	scheduler.Run()
+	defer scheduler.Shutdown()
+	time.Sleep(50 * time.Millisecond)
+
+	status := scheduler.Status(context.Background())
+	assert.True(t, status.Running)
+	assert.Equal(t, "replica-b", status.Leadership.LeaderInstance)
+	repo.AssertNotCalled(t, "ClaimPending", mock.Anything)
+}
+
+func TestScheduler_HoldsMessagesOutsideWindow(t *testing.T) {
+	// a window on a different weekday than today never contains now
+	day := [...]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}[(time.Now().UTC().Weekday()+3)%7]
+
+	repo := new(MockRepository)
+	cfg := &config.Config{WorkerInterval: time.Minute, WorkerBatchSize: 2, SendWindow: day + " 09:00-17:00"}
+	scheduler, err := service.NewScheduler(service.NewWorkerService(repo, nil, cfg), cfg)
+	assert.NoError(t, err)
+
+	scheduler.Run()
+	defer scheduler.Shutdown()
+	time.Sleep(50 * time.Millisecond)
+
+	status := scheduler.Status(context.Background())
+	assert.False(t, status.InWindow)
+	if assert.NotNil(t, status.NextTick) {
+		next := status.NextTick.UTC()
+		assert.Equal(t, day, next.Weekday().String()[:3])
+		assert.Equal(t, 9, next.Hour())
+	}
+	repo.AssertNotCalled(t, "ClaimPending", mock.Anything)
+}
+
+func TestScheduler_CronScheduleInWindowTimezone(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("ClaimPending", 2).Return([]model.Message{}, nil).Maybe()
+	cfg := &config.Config{WorkerInterval: time.Minute, WorkerBatchSize: 2}
+	scheduler, err := service.NewScheduler(service.NewWorkerService(repo, nil, cfg), cfg)
+	assert.NoError(t, err)
+
+	schedule, window := "30 9 * * *", "09:00-21:00 Europe/Istanbul"
+	assert.NoError(t, scheduler.Configure(service.SchedulerSettings{Schedule: &schedule, Window: &window}))
+
+	invalid := "61 * * * *"
+	assert.True(t, service.IsValidationError(scheduler.Configure(service.SchedulerSettings{Schedule: &invalid})))
+
+	// NextTick is only reported while running
+	assert.NoError(t, scheduler.Start("test"))
+	defer scheduler.Shutdown()
+
+	status := scheduler.Status(context.Background())
+	assert.Equal(t, schedule, status.Schedule)
+	istanbul, _ := time.LoadLocation("Europe/Istanbul")
+	if assert.NotNil(t, status.NextTick) {
+		next := status.NextTick.In(istanbul)
+		assert.Equal(t, 9, next.Hour())
+		assert.Equal(t, 30, next.Minute())
+	}
+}
+
+func TestScheduler_RunNowRefusesOverlap(t *testing.T) {
+	claimed := make(chan struct{})
+	release := make(chan struct{})
+	repo := new(MockRepository)
+	repo.On("ClaimPending", 25).Run(func(mock.Arguments) {
+		close(claimed)
+		<-release
+	}).Return([]model.Message{}, nil).Once()
+
+	// the desired state says running, but the timer loop isn't started so no tick interferes
+	state := new(MockSchedulerStateRepository)
+	state.On("Get").Return(&model.SchedulerState{}, nil)
+	cfg := &config.Config{WorkerInterval: time.Minute, WorkerBatchSize: 2}
+	scheduler, err := service.NewScheduler(service.NewWorkerService(repo, nil, cfg), cfg)
+	assert.NoError(t, err)
+	scheduler.State = state
+
+	done := make(chan service.BatchResult)
+	go func() {
+		result, err := scheduler.RunNow(25, "ops")
+		assert.NoError(t, err)
+		done <- result
+	}()
+	<-claimed
+
+	_, err = scheduler.RunNow(0, "ops")
+	assert.ErrorIs(t, err, service.ErrCycleRunning)
+
+	close(release)
+	result := <-done
+	assert.Equal(t, 0, result.Claimed)
+	assert.Equal(t, &result, scheduler.Status(context.Background()).LastResult)
+
+	_, err = scheduler.RunNow(service.MaxBatchSize+1, "ops")
+	assert.True(t, service.IsValidationError(err))
+	repo.AssertExpectations(t)
+}
+
+func TestScheduler_RunNowChecksLikeATick(t *testing.T) {
+	repo := new(MockRepository)
+	cfg := &config.Config{WorkerInterval: time.Minute, WorkerBatchSize: 2}
+	newScheduler := func(state *model.SchedulerState, err error) *service.Scheduler {
+		scheduler, serr := service.NewScheduler(service.NewWorkerService(repo, nil, cfg), cfg)
+		assert.NoError(t, serr)
+		states := new(MockSchedulerStateRepository)
+		states.On("Get").Return(state, err)
+		scheduler.State = states
+		return scheduler
+	}
+
+	_, err := newScheduler(&model.SchedulerState{Paused: true}, nil).RunNow(0, "ops")
+	assert.ErrorIs(t, err, service.ErrNotRunning)
+
+	_, err = newScheduler(nil, errors.New("connection refused")).RunNow(0, "ops")
+	assert.ErrorIs(t, err, service.ErrStateUnknown)
+
+	// a window on a different weekday than today never contains now
+	day := [...]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}[(time.Now().UTC().Weekday()+3)%7]
+	closed := newScheduler(&model.SchedulerState{}, nil)
+	window := day + " 09:00-17:00"
+	assert.NoError(t, closed.Configure(service.SchedulerSettings{Window: &window}))
+	_, err = closed.RunNow(0, "ops")
+	assert.ErrorIs(t, err, service.ErrOutsideWindow)
+
+	lock := new(MockLeaderLock)
+	lock.On("TryAcquire").Return(false, nil)
+	follower := newScheduler(&model.SchedulerState{}, nil)
+	follower.Elector = service.NewLeaderElector(lock, "replica-a", time.Minute)
+	_, err = follower.RunNow(0, "ops")
+	assert.ErrorIs(t, err, service.ErrNotLeader)
+
+	repo.AssertNotCalled(t, "ClaimPending", mock.Anything)
+}
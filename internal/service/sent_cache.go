@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+const (
+	sentCachePrefix = "msg:"   // msg:<remote id> holds the delivery record hash
+	sentByIDPrefix  = "msgid:" // msgid:<message id> holds the remote id
+)
+
+// ErrNotCached is returned by the lookups when no delivery record exists, e.g. it expired
+var ErrNotCached = errors.New("message is not in the cache")
+
+// SentCache records delivered messages in Redis so deliveries can be looked up by the
+// provider's ID or by ours without a database query. Each delivery is a hash under
+// msg:<remote id>, with msgid:<message id> pointing to it; both expire after TTL.
+type SentCache struct {
+	Redis  *redis.Client
+	TTL    time.Duration
+	Secret []byte // keys the recipient hash
+}
+
+// NewSentCache keys recipient hashes with secret. Without one a random key is used, so
+// hashes differ between replicas and restarts and can't be matched against a number.
+func NewSentCache(rdb *redis.Client, ttl time.Duration, secret string) *SentCache {
+	key := []byte(secret)
+	if len(key) == 0 {
+		slog.Warn("RECIPIENT_HASH_SECRET is not set, recipient hashes use a random per-process key")
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return &SentCache{Redis: rdb, TTL: ttl, Secret: key}
+}
+
+// CachedMessage is one delivery record. The recipient is only stored as an HMAC-SHA256 keyed
+// with the cache's secret, so whoever holds the secret can match the cache against a known
+// number, but the numbers can't be recovered by hashing every possible one.
+type CachedMessage struct {
+	RemoteID      string    `json:"remote_id"`
+	MessageID     string    `json:"message_id"`
+	RecipientHash string    `json:"recipient_hash"`
+	SentAt        time.Time `json:"sent_at"`
+	Attempt       int       `json:"attempt"` // 1 for the first send, incremented by every retry
+}
+
+// CachePage is one SCAN step. Redis treats the page size as a hint, so a page may hold
+// more or fewer entries, even none, while NextCursor is still set.
+type CachePage struct {
+	Entries    []CachedMessage `json:"entries"`
+	NextCursor string          `json:"next_cursor,omitempty"` // empty once the scan is complete
+}
+
+// HashRecipient returns the recipient hash stored in the cache
+func (c *SentCache) HashRecipient(to string) string {
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write([]byte(strings.TrimSpace(to)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Store writes the record and its reverse key in one round trip
+func (c *SentCache) Store(ctx context.Context, entry CachedMessage) error {
+	key := sentCachePrefix + entry.RemoteID
+	_, err := c.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"remote_id", entry.RemoteID,
+			"message_id", entry.MessageID,
+			"recipient_hash", entry.RecipientHash,
+			"sent_at", entry.SentAt.UTC().Format(time.RFC3339Nano),
+			"attempt", entry.Attempt,
+		)
+		pipe.Expire(ctx, key, c.TTL)
+		pipe.Set(ctx, sentByIDPrefix+entry.MessageID, entry.RemoteID, c.TTL)
+		return nil
+	})
+	return err
+}
+
+// ByRemoteID returns the record of the delivery the provider accepted under remoteID
+func (c *SentCache) ByRemoteID(ctx context.Context, remoteID string) (*CachedMessage, error) {
+	fields, err := c.Redis.HGetAll(ctx, sentCachePrefix+remoteID).Result()
+	if err != nil && !isWrongType(err) {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrNotCached
+	}
+	entry := parseCachedMessage(fields)
+	return &entry, nil
+}
+
+// ByMessageID returns the record of the last delivery of the message with our ID
+func (c *SentCache) ByMessageID(ctx context.Context, messageID uuid.UUID) (*CachedMessage, error) {
+	remoteID, err := c.Redis.Get(ctx, sentByIDPrefix+messageID.String()).Result()
+	if err == redis.Nil {
+		return nil, ErrNotCached
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.ByRemoteID(ctx, remoteID)
+}
+
+// List returns the entries of one SCAN step starting at cursor ("" or "0" for the first page).
+// SCAN doesn't block Redis the way KEYS does; the records of a page are read in one pipeline.
+func (c *SentCache) List(ctx context.Context, cursor string, count int) (*CachePage, error) {
+	var from uint64
+	if cursor != "" {
+		var err error
+		if from, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			return nil, &ValidationError{Problems: []string{"invalid cursor"}}
+		}
+	}
+
+	keys, next, err := c.Redis.Scan(ctx, from, sentCachePrefix+"*", int64(count)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CachePage{Entries: make([]CachedMessage, 0, len(keys))}
+	if next != 0 {
+		page.NextCursor = strconv.FormatUint(next, 10)
+	}
+	if len(keys) == 0 {
+		return page, nil
+	}
+
+	cmds := make([]*redis.StringStringMapCmd, len(keys))
+	_, err = c.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.HGetAll(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !isWrongType(err) {
+		return nil, err
+	}
+	for _, cmd := range cmds {
+		fields, err := cmd.Result()
+		if err != nil || len(fields) == 0 {
+			continue // expired after SCAN, or a plain string written before records were hashes
+		}
+		page.Entries = append(page.Entries, parseCachedMessage(fields))
+	}
+	return page, nil
+}
+
+func parseCachedMessage(fields map[string]string) CachedMessage {
+	entry := CachedMessage{
+		RemoteID:      fields["remote_id"],
+		MessageID:     fields["message_id"],
+		RecipientHash: fields["recipient_hash"],
+	}
+	entry.SentAt, _ = time.Parse(time.RFC3339Nano, fields["sent_at"])
+	entry.Attempt, _ = strconv.Atoi(fields["attempt"])
+	return entry
+}
+
+// isWrongType reports whether err comes from a key holding a plain string, as written
+// before delivery records were hashes. Those expire within REDIS_TTL of an upgrade.
+func isWrongType(err error) bool {
+	return strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
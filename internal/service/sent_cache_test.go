@@ -0,0 +1,106 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"insider-assessment/internal/service"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(t *testing.T) (*service.SentCache, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return service.NewSentCache(rdb, time.Hour, "secret"), mr
+}
+
+func TestSentCache_HashRecipientIsKeyed(t *testing.T) {
+	cache, _ := newTestCache(t)
+	other := service.NewSentCache(nil, time.Hour, "another secret")
+
+	hash := cache.HashRecipient(" +905551112233 ")
+	assert.Equal(t, hash, cache.HashRecipient("+905551112233"))
+	assert.NotEqual(t, hash, other.HashRecipient("+905551112233"))
+
+	plain := sha256.Sum256([]byte("+905551112233"))
+	assert.NotEqual(t, hex.EncodeToString(plain[:]), hash)
+}
+
+func TestSentCache_StoreAndLookups(t *testing.T) {
+	cache, mr := newTestCache(t)
+	ctx := context.Background()
+
+	id := uuid.New()
+	sentAt := time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC)
+	entry := service.CachedMessage{
+		RemoteID:      "remote-1",
+		MessageID:     id.String(),
+		RecipientHash: cache.HashRecipient("+905551112233"),
+		SentAt:        sentAt,
+		Attempt:       2,
+	}
+	require.NoError(t, cache.Store(ctx, entry))
+
+	byRemote, err := cache.ByRemoteID(ctx, "remote-1")
+	require.NoError(t, err)
+	assert.Equal(t, entry, *byRemote)
+
+	byID, err := cache.ByMessageID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, entry, *byID)
+
+	_, err = cache.ByRemoteID(ctx, "unknown")
+	assert.ErrorIs(t, err, service.ErrNotCached)
+	_, err = cache.ByMessageID(ctx, uuid.New())
+	assert.ErrorIs(t, err, service.ErrNotCached)
+
+	// both keys expire with the TTL
+	assert.Equal(t, time.Hour, mr.TTL("msg:remote-1"))
+	assert.Equal(t, time.Hour, mr.TTL("msgid:"+id.String()))
+	mr.FastForward(time.Hour)
+	_, err = cache.ByMessageID(ctx, id)
+	assert.ErrorIs(t, err, service.ErrNotCached)
+}
+
+func TestSentCache_ListPagesThroughRecords(t *testing.T) {
+	cache, mr := newTestCache(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, cache.Store(ctx, service.CachedMessage{
+			RemoteID:  "remote-" + string(rune('a'+i)),
+			MessageID: uuid.NewString(),
+			SentAt:    time.Now(),
+			Attempt:   1,
+		}))
+	}
+	// a plain string left over from before records were hashes is skipped
+	require.NoError(t, mr.Set("msg:legacy", "{}"))
+
+	seen := map[string]bool{}
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := cache.List(ctx, cursor, 2)
+		require.NoError(t, err)
+		for _, entry := range page.Entries {
+			seen[entry.RemoteID] = true
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	assert.Len(t, seen, 5)
+	assert.True(t, seen["remote-a"])
+	assert.False(t, seen["legacy"])
+
+	_, err := cache.List(ctx, "abc", 2)
+	assert.True(t, service.IsValidationError(err))
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"insider-assessment/internal/repository"
+	"log/slog"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const statsCacheKey = "stats:snapshot"
+
+// StatsService serves queue statistics, caching the aggregate queries briefly in Redis
+type StatsService struct {
+	Repo  repository.StatsRepository
+	Redis *redis.Client
+	TTL   time.Duration
+}
+
+func NewStatsService(repo repository.StatsRepository, rdb *redis.Client, ttl time.Duration) *StatsService {
+	return &StatsService{
+		Repo:  repo,
+		Redis: rdb,
+		TTL:   ttl,
+	}
+}
+
+// Get returns the cached snapshot when fresh, otherwise recomputes it.
+// Cache failures only cost an extra query, so they are logged and ignored.
+func (s *StatsService) Get(ctx context.Context) (*repository.Stats, error) {
+	if s.Redis != nil && s.TTL > 0 {
+		cached, err := s.Redis.Get(ctx, statsCacheKey).Bytes()
+		if err == nil {
+			var stats repository.Stats
+			if err := json.Unmarshal(cached, &stats); err == nil {
+				return &stats, nil
+			}
+		} else if err != redis.Nil {
+			slog.Warn("failed to read cached stats", "error", err)
+		}
+	}
+
+	stats, err := s.Repo.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Redis != nil && s.TTL > 0 {
+		if data, err := json.Marshal(stats); err == nil {
+			if err := s.Redis.Set(ctx, statsCacheKey, data, s.TTL).Err(); err != nil {
+				slog.Warn("failed to cache stats", "error", err)
+			}
+		}
+	}
+	return stats, nil
+}
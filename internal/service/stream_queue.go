@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// StreamQueue feeds the worker from a Redis stream read through a consumer group.
+//
+// Each entry carries a message ID. Entries are claimed in Postgres by ID, acknowledged
+// (XACK) and deleted once the message was handled, and entries a crashed consumer left
+// unacknowledged for ClaimTimeout are taken over with XAUTOCLAIM. Such an entry also takes
+// over its message if the message's claim is older than Lease; a message still within its
+// lease is left to the consumer holding it, or to the lease reaper once the lease runs out.
+//
+// When the stream holds fewer entries than a batch needs, the rest is claimed by polling
+// Postgres at most every PollInterval, which picks up messages that were never enqueued:
+// retries, campaigns, deferred messages, or ones whose XADD failed.
+type StreamQueue struct {
+	Redis        *redis.Client
+	Repo         repository.MessageRepository
+	Stream       string
+	Group        string
+	Consumer     string
+	ClaimTimeout time.Duration
+	Lease        time.Duration // 0 never takes over a message in PROCESSING
+	PollInterval time.Duration
+
+	mu       sync.Mutex
+	entries  map[uuid.UUID][]string // claimed message -> stream entries to acknowledge
+	lastPoll time.Time
+}
+
+func NewStreamQueue(rdb *redis.Client, repo repository.MessageRepository, stream, consumer string, claimTimeout time.Duration) *StreamQueue {
+	return &StreamQueue{
+		Redis:        rdb,
+		Repo:         repo,
+		Stream:       stream,
+		Group:        "workers",
+		Consumer:     consumer,
+		ClaimTimeout: claimTimeout,
+		PollInterval: time.Minute,
+		entries:      map[uuid.UUID][]string{},
+	}
+}
+
+// Init creates the stream and its consumer group unless they exist
+func (q *StreamQueue) Init(ctx context.Context) error {
+	err := q.Redis.XGroupCreateMkStream(ctx, q.Stream, q.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+func (q *StreamQueue) Enqueue(ctx context.Context, ids ...uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := q.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range ids {
+			pipe.XAdd(ctx, &redis.XAddArgs{Stream: q.Stream, Values: map[string]interface{}{"id": id.String()}})
+		}
+		return nil
+	})
+	return err
+}
+
+func (q *StreamQueue) Claim(ctx context.Context, limit int) ([]model.Message, error) {
+	entries, err := q.autoclaim(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) < limit {
+		fresh, err := q.read(ctx, limit-len(entries))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fresh...)
+	}
+
+	byMessage := map[uuid.UUID][]string{}
+	var ids []uuid.UUID
+	var drop []string
+	for _, entry := range entries {
+		id, err := uuid.Parse(fmt.Sprint(entry.Values["id"]))
+		if err != nil {
+			drop = append(drop, entry.ID) // deleted or malformed
+			continue
+		}
+		if _, seen := byMessage[id]; !seen {
+			ids = append(ids, id)
+		}
+		byMessage[id] = append(byMessage[id], entry.ID)
+	}
+
+	var messages []model.Message
+	if len(ids) > 0 {
+		var expiredBefore time.Time
+		if q.Lease > 0 {
+			expiredBefore = time.Now().Add(-q.Lease)
+		}
+		if messages, err = q.Repo.WithContext(ctx).ClaimByIDs(ids, expiredBefore); err != nil {
+			return nil, err // the entries stay pending and are reclaimed after ClaimTimeout
+		}
+	}
+
+	q.mu.Lock()
+	for _, msg := range messages {
+		q.entries[msg.ID] = byMessage[msg.ID]
+		delete(byMessage, msg.ID)
+	}
+	q.mu.Unlock()
+	// not claimable: already handled, cancelled, not due yet or still claimed within its
+	// lease. The database poll picks up the ones that become due later, and the lease reaper
+	// announces the ones whose claim expires.
+	for _, stale := range byMessage {
+		drop = append(drop, stale...)
+	}
+	if err := q.ack(ctx, drop...); err != nil {
+		slog.Warn("failed to acknowledge stale stream entries", "error", err)
+	}
+
+	if len(messages) < limit && q.pollDue() {
+		want := limit - len(messages)
+		polled, err := q.Repo.WithContext(ctx).ClaimPending(want)
+		if err != nil {
+			// the messages claimed by ID are in PROCESSING now and must be handled
+			slog.Error("failed to poll for pending messages", "error", err)
+			return messages, nil
+		}
+		if len(polled) < want {
+			q.mu.Lock()
+			q.lastPoll = time.Now()
+			q.mu.Unlock()
+		}
+		messages = append(messages, polled...)
+	}
+	return messages, nil
+}
+
+// pollDue reports whether PollInterval passed since the last database poll that drained the
+// backlog. A poll that fills its share of the batch doesn't count, so a backlog of messages
+// that were never enqueued is worked off at full speed.
+func (q *StreamQueue) pollDue() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return time.Since(q.lastPoll) >= q.PollInterval
+}
+
+func (q *StreamQueue) Ack(ctx context.Context, ids ...uuid.UUID) error {
+	var entryIDs []string
+	q.mu.Lock()
+	for _, id := range ids {
+		entryIDs = append(entryIDs, q.entries[id]...)
+		delete(q.entries, id)
+	}
+	q.mu.Unlock()
+	return q.ack(ctx, entryIDs...)
+}
+
+// ack acknowledges entries and deletes them so the stream doesn't grow without bound
+func (q *StreamQueue) ack(ctx context.Context, entryIDs ...string) error {
+	if len(entryIDs) == 0 {
+		return nil
+	}
+	_, err := q.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, q.Stream, q.Group, entryIDs...)
+		pipe.XDel(ctx, q.Stream, entryIDs...)
+		return nil
+	})
+	return err
+}
+
+// read returns up to count entries never delivered to any consumer, without blocking
+func (q *StreamQueue) read(ctx context.Context, count int) ([]redis.XMessage, error) {
+	streams, err := q.Redis.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    q.Group,
+		Consumer: q.Consumer,
+		Streams:  []string{q.Stream, ">"},
+		Count:    int64(count),
+		Block:    -1,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []redis.XMessage
+	for _, s := range streams {
+		entries = append(entries, s.Messages...)
+	}
+	return entries, nil
+}
+
+// autoclaim takes over up to count entries that were delivered but not acknowledged within
+// ClaimTimeout. It sends XAUTOCLAIM directly because the reply of Redis 7 has a third
+// element the client library doesn't expect.
+func (q *StreamQueue) autoclaim(ctx context.Context, count int) ([]redis.XMessage, error) {
+	reply, err := q.Redis.Do(ctx, "XAUTOCLAIM", q.Stream, q.Group, q.Consumer,
+		q.ClaimTimeout.Milliseconds(), "0-0", "COUNT", count).Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) < 2 {
+		return nil, fmt.Errorf("unexpected XAUTOCLAIM reply: %v", reply)
+	}
+
+	raw, _ := reply[1].([]interface{})
+	entries := make([]redis.XMessage, 0, len(raw))
+	for _, item := range raw {
+		pair, ok := item.([]interface{})
+		if !ok || len(pair) != 2 {
+			continue
+		}
+		entry := redis.XMessage{Values: map[string]interface{}{}}
+		entry.ID, _ = pair[0].(string)
+		fields, _ := pair[1].([]interface{}) // nil for entries deleted from the stream (Redis 6.2)
+		for i := 0; i+1 < len(fields); i += 2 {
+			entry.Values[fmt.Sprint(fields[i])] = fields[i+1]
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) > 0 {
+		slog.Info("reclaimed stuck stream entries", "count", len(entries), "consumer", q.Consumer)
+	}
+	return entries, nil
+}
@@ -0,0 +1,124 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/service"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStreamQueue(t *testing.T, rdb *redis.Client, repo *MockRepository, consumer string) *service.StreamQueue {
+	q := service.NewStreamQueue(rdb, repo, "queue:test", consumer, time.Millisecond)
+	q.Lease = 10 * time.Minute
+	q.PollInterval = time.Hour
+	require.NoError(t, q.Init(context.Background()))
+	return q
+}
+
+func newTestRedis(t *testing.T) *redis.Client {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+// expiresAroundLease matches the expiredBefore of a claim with a 10 minute lease
+var expiresAroundLease = mock.MatchedBy(func(before time.Time) bool {
+	return time.Until(before) < -9*time.Minute && time.Until(before) > -11*time.Minute
+})
+
+func TestStreamQueue_ClaimsEnqueuedAndAcks(t *testing.T) {
+	ctx := context.Background()
+	rdb := newTestRedis(t)
+	repo := new(MockRepository)
+	q := newTestStreamQueue(t, rdb, repo, "replica-a")
+
+	msg := model.Message{ID: uuid.New(), Status: model.StatusProcessing}
+	polled := model.Message{ID: uuid.New(), Status: model.StatusProcessing}
+	repo.On("ClaimByIDs", []uuid.UUID{msg.ID}, expiresAroundLease).Return([]model.Message{msg}, nil).Once()
+	repo.On("ClaimPending", 1).Return([]model.Message{polled}, nil).Once()
+
+	require.NoError(t, q.Enqueue(ctx, msg.ID))
+	claimed, err := q.Claim(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Message{msg, polled}, claimed)
+
+	require.NoError(t, q.Ack(ctx, msg.ID, polled.ID))
+	assert.Equal(t, int64(0), rdb.XLen(ctx, "queue:test").Val())
+	repo.AssertExpectations(t)
+}
+
+func TestStreamQueue_PollsDatabaseOnlyEveryPollInterval(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	q := newTestStreamQueue(t, newTestRedis(t), repo, "replica-a")
+
+	// a full poll means there may be more, so the next claim polls again
+	backlog := []model.Message{{ID: uuid.New()}, {ID: uuid.New()}}
+	repo.On("ClaimPending", 2).Return(backlog, nil).Once()
+	repo.On("ClaimPending", 2).Return([]model.Message{}, nil).Once()
+
+	for i := 0; i < 4; i++ {
+		_, err := q.Claim(ctx, 2)
+		require.NoError(t, err)
+	}
+	repo.AssertNumberOfCalls(t, "ClaimPending", 2)
+}
+
+func TestStreamQueue_KeepsClaimedMessagesWhenPollFails(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	q := newTestStreamQueue(t, newTestRedis(t), repo, "replica-a")
+
+	msg := model.Message{ID: uuid.New(), Status: model.StatusProcessing}
+	repo.On("ClaimByIDs", []uuid.UUID{msg.ID}, expiresAroundLease).Return([]model.Message{msg}, nil).Once()
+	repo.On("ClaimPending", 4).Return([]model.Message(nil), errors.New("connection reset")).Once()
+
+	require.NoError(t, q.Enqueue(ctx, msg.ID))
+	claimed, err := q.Claim(ctx, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Message{msg}, claimed)
+	repo.AssertExpectations(t)
+}
+
+func TestStreamQueue_ReclaimedEntryTakesOverExpiredClaim(t *testing.T) {
+	ctx := context.Background()
+	rdb := newTestRedis(t)
+	crashed := new(MockRepository)
+	repo := new(MockRepository)
+	a := newTestStreamQueue(t, rdb, crashed, "replica-a")
+	b := newTestStreamQueue(t, rdb, repo, "replica-b")
+
+	inFlight := model.Message{ID: uuid.New(), Status: model.StatusProcessing}
+	expired := model.Message{ID: uuid.New(), Status: model.StatusProcessing}
+	crashed.On("ClaimByIDs", mock.Anything, mock.Anything).Return([]model.Message{inFlight, expired}, nil).Once()
+	crashed.On("ClaimPending", mock.Anything).Return([]model.Message{}, nil).Maybe()
+
+	// replica-a claims both and never acknowledges them
+	require.NoError(t, a.Enqueue(ctx, inFlight.ID, expired.ID))
+	_, err := a.Claim(ctx, 2)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	// only the message whose lease expired is taken over; the other entry is dropped and
+	// left to the lease reaper
+	repo.On("ClaimByIDs", []uuid.UUID{inFlight.ID, expired.ID}, expiresAroundLease).Return([]model.Message{expired}, nil).Once()
+	repo.On("ClaimPending", 1).Return([]model.Message{}, nil).Once()
+	claimed, err := b.Claim(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Message{expired}, claimed)
+	assert.Equal(t, int64(1), rdb.XLen(ctx, "queue:test").Val())
+
+	require.NoError(t, b.Ack(ctx, expired.ID))
+	assert.Equal(t, int64(0), rdb.XLen(ctx, "queue:test").Val())
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"errors"
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"insider-assessment/pkg/phone"
+	"strings"
+)
+
+// ErrSuppressed is returned when a message is addressed to a recipient who opted out
+var ErrSuppressed = errors.New("recipient has opted out")
+
+// KeywordAction is what an inbound message did to its sender's suppression
+type KeywordAction string
+
+const (
+	KeywordOptOut KeywordAction = "opted_out"
+	KeywordOptIn  KeywordAction = "opted_in"
+	KeywordNone   KeywordAction = "none"
+)
+
+// SuppressionService keeps the opt-out list that every send is checked against
+type SuppressionService struct {
+	Repo   repository.SuppressionRepository
+	optOut map[string]bool
+	optIn  map[string]bool
+}
+
+// NewSuppressionService takes the comma-separated keywords that opt a sender out and back in
+func NewSuppressionService(repo repository.SuppressionRepository, optOutKeywords, optInKeywords string) *SuppressionService {
+	return &SuppressionService{Repo: repo, optOut: keywordSet(optOutKeywords), optIn: keywordSet(optInKeywords)}
+}
+
+// Add suppresses a number. It returns repository.ErrConflict when it already is.
+func (s *SuppressionService) Add(number, reason, source string) (*model.Suppression, error) {
+	normalized := phone.Normalize(number)
+	if normalized == "" {
+		return nil, &ValidationError{Problems: []string{"phone is required"}}
+	}
+	if source == "" {
+		source = model.SuppressionSourceAPI
+	}
+
+	suppression := model.Suppression{Phone: normalized, Reason: strings.TrimSpace(reason), Source: source}
+	if err := s.Repo.Add(&suppression); err != nil {
+		return nil, err
+	}
+	return &suppression, nil
+}
+
+// Remove lifts the suppression of a number
+func (s *SuppressionService) Remove(number string) error {
+	return s.Repo.Remove(phone.Normalize(number))
+}
+
+func (s *SuppressionService) List(cursor string, limit int) ([]model.Suppression, string, error) {
+	return s.Repo.List(cursor, limit)
+}
+
+// Check returns ErrSuppressed when the recipient opted out
+func (s *SuppressionService) Check(to string) error {
+	suppressed, err := s.Suppressed([]string{to})
+	if err != nil {
+		return err
+	}
+	if suppressed[to] {
+		return ErrSuppressed
+	}
+	return nil
+}
+
+// Suppressed reports which of the recipients opted out, keyed as given
+func (s *SuppressionService) Suppressed(recipients []string) (map[string]bool, error) {
+	normalized := make([]string, len(recipients))
+	for i, to := range recipients {
+		normalized[i] = phone.Normalize(to)
+	}
+	found, err := s.Repo.Suppressed(normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	suppressed := map[string]bool{}
+	for i, to := range recipients {
+		if found[normalized[i]] {
+			suppressed[to] = true
+		}
+	}
+	return suppressed, nil
+}
+
+// HandleInbound applies an opt-out or opt-in keyword sent by from. The whole message must
+// be the keyword, ignoring case and surrounding punctuation, so a reply like "please don't
+// stop" changes nothing. Repeating a keyword is harmless.
+func (s *SuppressionService) HandleInbound(from, text string) (KeywordAction, error) {
+	keyword := normalizeKeyword(text)
+	switch {
+	case s.optOut[keyword]:
+		_, err := s.Add(from, "replied "+keyword, model.SuppressionSourceInbound)
+		if err != nil && !errors.Is(err, repository.ErrConflict) {
+			return KeywordNone, err
+		}
+		return KeywordOptOut, nil
+	case s.optIn[keyword]:
+		if err := s.Remove(from); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return KeywordNone, err
+		}
+		return KeywordOptIn, nil
+	}
+	return KeywordNone, nil
+}
+
+func keywordSet(list string) map[string]bool {
+	set := map[string]bool{}
+	for _, keyword := range strings.Split(list, ",") {
+		if keyword = normalizeKeyword(keyword); keyword != "" {
+			set[keyword] = true
+		}
+	}
+	return set
+}
+
+func normalizeKeyword(text string) string {
+	return strings.ToUpper(strings.Trim(text, " \t\r\n.!?\"'"))
+}
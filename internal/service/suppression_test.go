@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
+	"insider-assessment/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSuppressionRepository is a mock implementation of repository.SuppressionRepository
+type MockSuppressionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSuppressionRepository) Add(s *model.Suppression) error {
+	return m.Called(s).Error(0)
+}
+
+func (m *MockSuppressionRepository) Remove(phone string) error {
+	return m.Called(phone).Error(0)
+}
+
+func (m *MockSuppressionRepository) List(cursor string, limit int) ([]model.Suppression, string, error) {
+	args := m.Called(cursor, limit)
+	return args.Get(0).([]model.Suppression), args.String(1), args.Error(2)
+}
+
+func (m *MockSuppressionRepository) Suppressed(phones []string) (map[string]bool, error) {
+	args := m.Called(phones)
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
+func TestSuppressionService_HandleInbound(t *testing.T) {
+	repo := new(MockSuppressionRepository)
+	svc := service.NewSuppressionService(repo, "STOP,UNSUBSCRIBE", "START")
+
+	repo.On("Add", mock.MatchedBy(func(s *model.Suppression) bool {
+		return s.Phone == "+905551112233" && s.Source == model.SuppressionSourceInbound && s.Reason == "replied STOP"
+	})).Return(nil).Once()
+	action, err := svc.HandleInbound("+90 555 111 22 33", " stop! ")
+	require.NoError(t, err)
+	assert.Equal(t, service.KeywordOptOut, action)
+
+	// a repeated STOP is not an error
+	repo.On("Add", mock.Anything).Return(repository.ErrConflict).Once()
+	action, err = svc.HandleInbound("+905551112233", "STOP")
+	require.NoError(t, err)
+	assert.Equal(t, service.KeywordOptOut, action)
+
+	repo.On("Remove", "+905551112233").Return(repository.ErrNotFound).Once()
+	action, err = svc.HandleInbound("00905551112233", "Start")
+	require.NoError(t, err)
+	assert.Equal(t, service.KeywordOptIn, action)
+
+	action, err = svc.HandleInbound("+905551112233", "please don't stop")
+	require.NoError(t, err)
+	assert.Equal(t, service.KeywordNone, action)
+	repo.AssertExpectations(t)
+}
+
+func TestSuppressionService_Check(t *testing.T) {
+	repo := new(MockSuppressionRepository)
+	svc := service.NewSuppressionService(repo, "STOP", "START")
+
+	repo.On("Suppressed", []string{"+905551112233"}).Return(map[string]bool{"+905551112233": true}, nil)
+	assert.ErrorIs(t, svc.Check("+90 555 111 22 33"), service.ErrSuppressed)
+	// a STOP recorded with '+' also holds for the number written without it
+	assert.ErrorIs(t, svc.Check("905551112233"), service.ErrSuppressed)
+
+	_, err := svc.Add("  ", "", "")
+	assert.True(t, service.IsValidationError(err))
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var placeholderPattern = regexp.MustCompile(`{{\s*([A-Za-z0-9_]+)\s*}}`)
+
+// RenderTemplate replaces {{name}} placeholders with the matching variable.
+// A placeholder without a value is an error so we never send "Hello {{name}}".
+func RenderTemplate(tmpl string, vars map[string]string) (string, error) {
+	var missing []string
+	out := placeholderPattern.ReplaceAllStringFunc(tmpl, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		val, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+			return match
+		}
+		return val
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing template variables: %s", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
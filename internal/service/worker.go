@@ -4,29 +4,54 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"insider-assessment/internal/config"
 	"insider-assessment/internal/model"
 	"insider-assessment/internal/repository"
+	"insider-assessment/pkg/metrics"
+	"insider-assessment/pkg/tracing"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// dedupeRecheck is how long a copy of a message being sent waits before it is checked again
+const dedupeRecheck = time.Minute
+
 type WorkerService struct {
-	Repo   repository.MessageRepository
-	Redis  *redis.Client
-	Config *config.Config
+	Repo         repository.MessageRepository
+	Redis        *redis.Client
+	Config       *config.Config
+	QuietHours   *QuietHoursPolicy   // optional; messages claimed during quiet hours are deferred
+	Cache        *SentCache          // nil without Redis
+	Queue        Queue               // where batches are claimed from, the messages table by default
+	Updates      *Broadcaster        // optional; receives every status transition the worker makes
+	Suppressions *SuppressionService // optional; messages to opted-out recipients are suppressed
+	Client       *http.Client        // calls the webhook; its timeout bounds how long a batch can take
 }
 
 func NewWorkerService(repo repository.MessageRepository, rdb *redis.Client, cfg *config.Config) *WorkerService {
-	return &WorkerService{
+	s := &WorkerService{
 		Repo:   repo,
 		Redis:  rdb,
 		Config: cfg,
+		Queue:  NewDBQueue(repo),
+		Client: &http.Client{Timeout: cfg.WebhookTimeout},
+	}
+	if rdb != nil {
+		s.Cache = NewSentCache(rdb, cfg.RedisTTL, cfg.RecipientHashSecret)
 	}
+	return s
 }
 
 type WebhookResponse struct {
@@ -34,67 +59,333 @@ type WebhookResponse struct {
 	MessageID string `json:"messageId"`
 }
 
-// ProcessMessages picks up messages and attempts to send them
-func (s *WorkerService) ProcessMessages() {
+// BatchResult summarizes one scheduler cycle
+type BatchResult struct {
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Claimed    int       `json:"claimed"`
+	Sent       int       `json:"sent"`
+	Failed     int       `json:"failed"`
+	Deferred   int       `json:"deferred"`
+	Suppressed int       `json:"suppressed"`
+	Duplicates int       `json:"duplicates"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ProcessMessages claims a batch of the configured size and attempts to send it
+func (s *WorkerService) ProcessMessages() BatchResult {
+	return s.ProcessBatch(s.Config.WorkerBatchSize)
+}
+
+// ProcessBatch claims up to size pending messages, sends them in parallel and waits for every webhook call
+func (s *WorkerService) ProcessBatch(size int) (result BatchResult) {
 	slog.Info("--- Ticker: Checking for pending messages ---")
+	result.StartedAt = time.Now()
+	defer func() { result.DurationMs = time.Since(result.StartedAt).Milliseconds() }()
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "scheduler.tick",
+		trace.WithAttributes(attribute.Int("batch_size", size)))
+	defer span.End()
 
-	messages, err := s.Repo.GetPending(s.Config.WorkerBatchSize)
+	messages, err := s.Queue.Claim(ctx, size)
 	if err != nil {
 		slog.Error("error fetching messages", "error", err)
-		return
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		result.Error = err.Error()
+		return result
+	}
+	result.Claimed = len(messages)
+	span.SetAttributes(attribute.Int("messages.claimed", len(messages)))
+	defer s.ack(ctx, messages)
+	for _, msg := range messages {
+		s.broadcast(msg, model.StatusPending, model.StatusProcessing)
 	}
 
 	if len(messages) == 0 {
 		slog.Info("no pending messages found.")
+		return result
+	}
+
+	messages, result.Suppressed = s.dropSuppressed(ctx, messages)
+	span.SetAttributes(attribute.Int("messages.suppressed", result.Suppressed))
+	var held int
+	messages, result.Duplicates, held = s.dropDuplicates(ctx, messages)
+	span.SetAttributes(attribute.Int("messages.duplicates", result.Duplicates))
+	messages, result.Deferred = s.deferQuiet(ctx, messages)
+	result.Deferred += held
+	span.SetAttributes(attribute.Int("messages.deferred", result.Deferred))
+
+	tick := trace.LinkFromContext(ctx)
+	statuses := make([]model.MessageStatus, len(messages))
+	var wg sync.WaitGroup
+	for i, msg := range messages {
+		wg.Add(1)
+		go func(i int, msg model.Message) { // send in parallel
+			defer wg.Done()
+			statuses[i] = s.sendMessage(msg, tick)
+		}(i, msg)
+	}
+	wg.Wait()
+
+	for _, status := range statuses {
+		if status == model.StatusSent {
+			result.Sent++
+		} else {
+			result.Failed++
+		}
+	}
+	return result
+}
+
+// ack tells the queue the claimed messages were handled, whether sent, failed or deferred
+func (s *WorkerService) ack(ctx context.Context, messages []model.Message) {
+	if len(messages) == 0 {
 		return
 	}
+	ids := make([]uuid.UUID, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+	if err := s.Queue.Ack(ctx, ids...); err != nil {
+		slog.Warn("failed to acknowledge messages", "count", len(ids), "error", err)
+	}
+}
+
+// dropSuppressed ends the messages whose recipient opted out and returns the others. If the
+// opt-out list can't be read, nothing is sent: the batch goes back to the queue instead.
+func (s *WorkerService) dropSuppressed(ctx context.Context, messages []model.Message) ([]model.Message, int) {
+	if s.Suppressions == nil {
+		return messages, 0
+	}
+
+	recipients := make([]string, len(messages))
+	for i, msg := range messages {
+		recipients[i] = msg.To
+	}
+	suppressed, err := s.Suppressions.Suppressed(recipients)
+	if err != nil {
+		slog.Error("failed to check suppressions, deferring batch", "error", err)
+		until := time.Now().Add(time.Minute)
+		for _, msg := range messages {
+			if err := s.Repo.WithContext(ctx).Defer(msg.ID, until); err != nil {
+				slog.Error("failed to defer message", "id", msg.ID, "error", err)
+				continue
+			}
+			s.broadcast(msg, model.StatusProcessing, model.StatusPending)
+		}
+		return nil, 0
+	}
+
+	var allowed []model.Message
+	dropped := 0
+	for _, msg := range messages {
+		if !suppressed[msg.To] {
+			allowed = append(allowed, msg)
+			continue
+		}
+		if err := s.Repo.WithContext(ctx).Suppress(msg.ID); err != nil {
+			slog.Error("failed to suppress message", "id", msg.ID, "error", err)
+			continue
+		}
+		slog.Info("suppressed message to opted-out recipient", "id", msg.ID)
+		s.broadcast(msg, model.StatusProcessing, model.StatusSuppressed)
+		dropped++
+	}
+	return allowed, dropped
+}
+
+// dropDuplicates ends the messages whose content already went to the same recipient within
+// the dedupe window and returns the others. Of identical messages claimed at the same time,
+// in this batch or another, only the oldest is sent; the copies are handed back to the queue
+// and checked again once it completed, so a copy still goes out if the original failed. If
+// the messages can't be looked up, the batch is sent unchecked.
+func (s *WorkerService) dropDuplicates(ctx context.Context, messages []model.Message) ([]model.Message, int, int) {
+	window := s.Config.DedupeWindow
+	if window <= 0 || len(messages) == 0 {
+		return messages, 0, 0
+	}
+
+	hashes := make([]string, len(messages))
+	for i, msg := range messages {
+		hashes[i] = model.ContentHash(msg.To, msg.Content) // rows stored before hashing have none
+	}
+	originals, err := s.Repo.WithContext(ctx).RecentlySent(hashes, time.Now().Add(-window))
+	if err != nil {
+		slog.Error("failed to look up recently sent messages, skipping dedupe", "error", err)
+		return messages, 0, 0
+	}
+	inFlight, err := s.Repo.WithContext(ctx).InFlight(hashes)
+	if err != nil {
+		slog.Error("failed to look up messages being sent, skipping dedupe", "error", err)
+		return messages, 0, 0
+	}
+
+	var unique []model.Message
+	dropped, held := 0, 0
+	recheck := time.Now().Add(dedupeRecheck)
+	for i, msg := range messages {
+		if original, ok := originals[hashes[i]]; ok {
+			if err := s.Repo.WithContext(ctx).MarkDuplicate(msg.ID, original); err != nil {
+				slog.Error("failed to mark message as duplicate", "id", msg.ID, "error", err)
+				continue
+			}
+			slog.Info("skipped duplicate message", "id", msg.ID, "duplicate_of", original)
+			s.broadcast(msg, model.StatusProcessing, model.StatusDuplicate)
+			dropped++
+			continue
+		}
+
+		oldest, ok := inFlight[hashes[i]]
+		if !ok {
+			inFlight[hashes[i]] = msg.ID // later copies in this batch wait for this one
+		}
+		if !ok || oldest == msg.ID {
+			unique = append(unique, msg)
+			continue
+		}
+		if err := s.Repo.WithContext(ctx).Defer(msg.ID, recheck); err != nil {
+			slog.Error("failed to defer message", "id", msg.ID, "error", err)
+			continue
+		}
+		slog.Info("deferred copy of a message being sent", "id", msg.ID, "copy_of", oldest, "until", recheck)
+		s.broadcast(msg, model.StatusProcessing, model.StatusPending)
+		held++
+	}
+	return unique, dropped, held
+}
 
+// deferQuiet hands messages whose recipient is in quiet hours back to the queue until the
+// quiet hours end and returns the ones that may be sent now
+func (s *WorkerService) deferQuiet(ctx context.Context, messages []model.Message) ([]model.Message, int) {
+	if s.QuietHours.Empty() {
+		return messages, 0
+	}
+
+	now := time.Now()
+	var due []model.Message
+	deferred := 0
 	for _, msg := range messages {
-		go s.sendMessage(msg) // send in parallel
+		until := s.QuietHours.NextEligible(msg, now)
+		if !until.After(now) {
+			due = append(due, msg)
+			continue
+		}
+		if err := s.Repo.WithContext(ctx).Defer(msg.ID, until); err != nil {
+			slog.Error("failed to defer message", "id", msg.ID, "error", err)
+			continue
+		}
+		slog.Info("deferred message during quiet hours", "id", msg.ID, "category", msg.Category, "until", until)
+		s.broadcast(msg, model.StatusProcessing, model.StatusPending)
+		deferred++
 	}
+	return due, deferred
 }
 
-func (s *WorkerService) sendMessage(msg model.Message) {
+// sendMessage continues the trace of the API call that created msg, linking back to the
+// scheduler tick that claimed it. Messages without a stored trace start their own.
+func (s *WorkerService) sendMessage(msg model.Message, tick trace.Link) model.MessageStatus {
+	ctx := tracing.ContextWithRemoteParent(context.Background(), msg.TraceID, msg.SpanID, msg.TraceFlags)
+	ctx, span := tracing.Tracer().Start(ctx, "webhook.send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithLinks(tick),
+		trace.WithAttributes(
+			attribute.String("message.id", msg.ID.String()),
+			semconv.HTTPRequestMethodPost,
+			semconv.URLFull(s.Config.WebhookUrl),
+		),
+	)
+	defer span.End()
+
 	payload := map[string]string{
 		"to":      msg.To,
 		"content": msg.Content,
 	}
 	jsonVal, _ := json.Marshal(payload)
 
-	resp, err := http.Post(s.Config.WebhookUrl, "application/json", bytes.NewBuffer(jsonVal))
+	start := time.Now()
+	resp, err := s.postWebhook(ctx, jsonVal)
+	latency := time.Since(start)
 	if err != nil {
 		slog.Error("failed to send message", "id", msg.ID, "error", err)
-		s.Repo.UpdateStatus(msg.ID, model.StatusFailed)
-		return
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.complete(ctx, msg, model.StatusFailed, latency, "")
+		return model.StatusFailed
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
 
 	if resp.StatusCode == 200 || resp.StatusCode == 202 {
 		var result WebhookResponse
 		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 			slog.Error("failed to decode response", "id", msg.ID, "error", err)
 		}
+		span.SetAttributes(attribute.String("message.remote_id", result.MessageID))
 
 		// update DB
-		s.Repo.UpdateStatus(msg.ID, model.StatusSent)
+		s.complete(ctx, msg, model.StatusSent, latency, result.MessageID)
 		slog.Info("message sent successfully", "id", msg.ID, "remote_id", result.MessageID)
 
 		// cache to Redis
-		if s.Redis != nil && result.MessageID != "" {
-			ctx := context.Background()
-			key := fmt.Sprintf("msg:%s", result.MessageID)
-			val := fmt.Sprintf("sent at: %s | DB_ID: %s", time.Now().Format(time.RFC3339), msg.ID.String())
-
-			err := s.Redis.Set(ctx, key, val, s.Config.RedisTTL).Err()
-			if err != nil {
+		if s.Cache != nil && result.MessageID != "" {
+			entry := CachedMessage{
+				RemoteID:      result.MessageID,
+				MessageID:     msg.ID.String(),
+				RecipientHash: s.Cache.HashRecipient(msg.To),
+				SentAt:        time.Now(),
+				Attempt:       msg.RetryCount + 1,
+			}
+			if err := s.Cache.Store(ctx, entry); err != nil {
 				slog.Error("redis error", "error", err)
 			} else {
 				slog.Info("cached msg to Redis", "remote_id", result.MessageID)
 			}
 		}
+		return model.StatusSent
+	}
 
+	slog.Warn("webhook returned non-OK status", "status", resp.StatusCode)
+	span.SetStatus(codes.Error, resp.Status)
+	s.complete(ctx, msg, model.StatusFailed, latency, "")
+	return model.StatusFailed
+}
+
+// postWebhook sends the payload with the W3C traceparent of ctx so the provider can join the trace
+func (s *WorkerService) postWebhook(ctx context.Context, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Config.WebhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return s.Client.Do(req)
+}
+
+// complete stores the webhook outcome, which also queues its lifecycle event, and records
+// it in the metrics
+func (s *WorkerService) complete(ctx context.Context, msg model.Message, status model.MessageStatus, latency time.Duration, remoteID string) {
+	err := s.Repo.WithContext(ctx).CompleteDelivery(msg.ID, msg.ClaimedAt, status, latency, remoteID)
+	if errors.Is(err, repository.ErrConflict) {
+		// the claim expired and the message was released, taken over or cancelled meanwhile
+		slog.Warn("discarded outcome of an expired claim", "id", msg.ID, "status", status, "claimed_at", msg.ClaimedAt)
+	} else if err != nil {
+		slog.Error("failed to update message status", "id", msg.ID, "status", status, "error", err)
 	} else {
-		slog.Warn("webhook returned non-OK status", "status", resp.StatusCode)
-		s.Repo.UpdateStatus(msg.ID, model.StatusFailed)
+		s.broadcast(msg, model.StatusProcessing, status)
 	}
+
+	metrics.MessagesDelivered.WithLabelValues(string(status), metrics.ChannelSMS).Inc()
+	metrics.WebhookLatency.WithLabelValues(metrics.ChannelSMS).Observe(latency.Seconds())
+}
+
+// broadcast announces a committed transition to live listeners
+func (s *WorkerService) broadcast(msg model.Message, from, to model.MessageStatus) {
+	s.Updates.Publish(StatusUpdate{
+		MessageID:  msg.ID,
+		To:         msg.To,
+		CampaignID: msg.CampaignID,
+		From:       from,
+		Status:     to,
+	})
 }
@@ -1,18 +1,29 @@
 package service_test
 
 import (
+	"context"
 	"encoding/json"
 	"insider-assessment/internal/config"
 	"insider-assessment/internal/model"
+	"insider-assessment/internal/repository"
 	"insider-assessment/internal/service"
+	"insider-assessment/pkg/metrics"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 // MockRepository is a mock implementation of repository.MessageRepository
@@ -20,26 +31,126 @@ type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) GetPending(limit int) ([]model.Message, error) {
+func (m *MockRepository) WithContext(ctx context.Context) repository.MessageRepository {
+	return m
+}
+
+func (m *MockRepository) ClaimPending(limit int) ([]model.Message, error) {
 	args := m.Called(limit)
 	return args.Get(0).([]model.Message), args.Error(1)
 }
 
+func (m *MockRepository) ClaimByIDs(ids []uuid.UUID, expiredBefore time.Time) ([]model.Message, error) {
+	args := m.Called(ids, expiredBefore)
+	if msgs, ok := args.Get(0).([]model.Message); ok {
+		return msgs, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) ReleaseExpired(claimedBefore time.Time, limit int) ([]model.Message, error) {
+	args := m.Called(claimedBefore, limit)
+	if msgs, ok := args.Get(0).([]model.Message); ok {
+		return msgs, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) Cancel(id uuid.UUID, actor string) (*model.Message, error) {
+	args := m.Called(id, actor)
+	if msg, ok := args.Get(0).(*model.Message); ok {
+		return msg, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockRepository) UpdateStatus(id uuid.UUID, status model.MessageStatus) error {
 	args := m.Called(id, status)
 	return args.Error(0)
 }
 
-func (m *MockRepository) GetAllSent() ([]model.Message, error) {
-	args := m.Called()
+func (m *MockRepository) Retry(id uuid.UUID, actor string) (*model.Message, error) {
+	args := m.Called(id, actor)
+	if msg, ok := args.Get(0).(*model.Message); ok {
+		return msg, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) Suppress(id uuid.UUID) error {
+	return m.Called(id).Error(0)
+}
+
+func (m *MockRepository) MarkDuplicate(id, originalID uuid.UUID) error {
+	return m.Called(id, originalID).Error(0)
+}
+
+func (m *MockRepository) RecentlySent(hashes []string, since time.Time) (map[string]uuid.UUID, error) {
+	args := m.Called(hashes, since)
+	return args.Get(0).(map[string]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) InFlight(hashes []string) (map[string]uuid.UUID, error) {
+	args := m.Called(hashes)
+	return args.Get(0).(map[string]uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) Defer(id uuid.UUID, until time.Time) error {
+	args := m.Called(id, until)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RetryFailed(filter repository.RetryFilter, actor string) ([]model.Message, error) {
+	args := m.Called(filter, actor)
 	return args.Get(0).([]model.Message), args.Error(1)
 }
 
+func (m *MockRepository) List(filter repository.MessageFilter) ([]model.Message, string, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.Message), args.String(1), args.Error(2)
+}
+
+func (m *MockRepository) CompleteDelivery(id uuid.UUID, claimedAt *time.Time, status model.MessageStatus, latency time.Duration, remoteID string) error {
+	args := m.Called(id, claimedAt, status, latency, remoteID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetByID(id uuid.UUID) (*model.Message, error) {
+	args := m.Called(id)
+	if msg, ok := args.Get(0).(*model.Message); ok {
+		return msg, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) History(id uuid.UUID) ([]model.StatusChange, error) {
+	args := m.Called(id)
+	return args.Get(0).([]model.StatusChange), args.Error(1)
+}
+
 func (m *MockRepository) Create(msg *model.Message) error {
 	args := m.Called(msg)
 	return args.Error(0)
 }
 
+func (m *MockRepository) CreateBatch(msgs []model.Message) error {
+	args := m.Called(msgs)
+	return args.Error(0)
+}
+
+// deliveredCount reads the delivery counter for one status
+func deliveredCount(status model.MessageStatus) float64 {
+	return testutil.ToFloat64(metrics.MessagesDelivered.WithLabelValues(string(status), metrics.ChannelSMS))
+}
+
+// latencyCount reads how many webhook calls the latency histogram observed
+func latencyCount(t *testing.T) uint64 {
+	var m dto.Metric
+	hist := metrics.WebhookLatency.WithLabelValues(metrics.ChannelSMS).(prometheus.Histogram)
+	require.NoError(t, hist.Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
 func TestWorkerService_ProcessMessages_Success(t *testing.T) {
 	// 1. Setup Mock Webhook Server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -71,8 +182,8 @@ func TestWorkerService_ProcessMessages_Success(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetPending", 2).Return(messages, nil)
-	mockRepo.On("UpdateStatus", msgID, model.StatusSent).Return(nil)
+	mockRepo.On("ClaimPending", 2).Return(messages, nil)
+	mockRepo.On("CompleteDelivery", msgID, mock.Anything, model.StatusSent, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil)
 
 	// 3. Setup Service
 	cfg := &config.Config{
@@ -84,19 +195,17 @@ func TestWorkerService_ProcessMessages_Success(t *testing.T) {
 	// Passing nil for Redis to skip cache logic
 	svc := service.NewWorkerService(mockRepo, nil, cfg)
 
-	// 4. Execute
-	// ProcessMessages runs goroutines, so we might need to wait a tiny bit or use a WaitGroup if we could inject it.
-	// However, ProcessMessages launches goroutines and returns. The goroutines run independently.
-	// Since we can't easily wait for the internal goroutines of ProcessMessages without modifying the code,
-	// we will rely on a small sleep or refactor.
-	// For this test, let's try a small sleep which is flaky but simple for now,
-	// or better: refactor the service to accept a WaitGroup or return a channel?
-	// Given the constraints, let's assume the operations are fast enough or use a small sleep.
-
-	svc.ProcessMessages()
+	sentBefore, failedBefore, observedBefore := deliveredCount(model.StatusSent), deliveredCount(model.StatusFailed), latencyCount(t)
 
-	// Wait for goroutines to finish
-	time.Sleep(100 * time.Millisecond)
+	// 4. Execute
+	// ProcessMessages waits for the webhook calls, so the result is final when it returns
+	result := svc.ProcessMessages()
+	assert.Equal(t, 1, result.Claimed)
+	assert.Equal(t, 1, result.Sent)
+	assert.Equal(t, 0, result.Failed)
+	assert.Equal(t, sentBefore+1, deliveredCount(model.StatusSent))
+	assert.Equal(t, failedBefore, deliveredCount(model.StatusFailed))
+	assert.Equal(t, observedBefore+1, latencyCount(t))
 
 	// 5. Verify
 	mockRepo.AssertExpectations(t)
@@ -121,8 +230,8 @@ func TestWorkerService_ProcessMessages_Failure(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetPending", 2).Return(messages, nil)
-	mockRepo.On("UpdateStatus", msgID, model.StatusFailed).Return(nil)
+	mockRepo.On("ClaimPending", 2).Return(messages, nil)
+	mockRepo.On("CompleteDelivery", msgID, mock.Anything, model.StatusFailed, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil)
 
 	// 3. Setup Service
 	cfg := &config.Config{
@@ -132,10 +241,324 @@ func TestWorkerService_ProcessMessages_Failure(t *testing.T) {
 
 	svc := service.NewWorkerService(mockRepo, nil, cfg)
 
+	sentBefore, failedBefore, observedBefore := deliveredCount(model.StatusSent), deliveredCount(model.StatusFailed), latencyCount(t)
+
 	// 4. Execute
-	svc.ProcessMessages()
-	time.Sleep(100 * time.Millisecond)
+	result := svc.ProcessMessages()
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, sentBefore, deliveredCount(model.StatusSent))
+	assert.Equal(t, failedBefore+1, deliveredCount(model.StatusFailed))
+	assert.Equal(t, observedBefore+1, latencyCount(t))
 
 	// 5. Verify
 	mockRepo.AssertExpectations(t)
 }
+
+func TestWorkerService_WebhookTimeoutFailsMessage(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release // a provider that never answers
+	}))
+	defer server.Close()
+	defer close(release)
+
+	msg := model.Message{ID: uuid.New(), To: "+1234567890", Content: "Hello"}
+	mockRepo := new(MockRepository)
+	mockRepo.On("ClaimPending", 1).Return([]model.Message{msg}, nil)
+	mockRepo.On("CompleteDelivery", msg.ID, mock.Anything, model.StatusFailed, mock.AnythingOfType("time.Duration"), "").Return(nil)
+
+	svc := service.NewWorkerService(mockRepo, nil, &config.Config{WebhookUrl: server.URL, WebhookTimeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	result := svc.ProcessBatch(1)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, result.Failed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWorkerService_SendPropagatesStoredTrace(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	traceparent := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent <- r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"messageId": "external-456"})
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockRepository)
+	msgID := uuid.New()
+	mockRepo.On("ClaimPending", 1).Return([]model.Message{{
+		ID:      msgID,
+		To:      "+1234567890",
+		Content: "Traced",
+		Status:  model.StatusPending,
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+	}}, nil)
+	mockRepo.On("CompleteDelivery", msgID, mock.Anything, model.StatusSent, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil)
+
+	svc := service.NewWorkerService(mockRepo, nil, &config.Config{WebhookUrl: server.URL, WorkerBatchSize: 1})
+	svc.ProcessMessages()
+
+	select {
+	case header := <-traceparent:
+		assert.Contains(t, header, "-4bf92f3577b34da6a3ce929d0e0e4736-")
+		assert.True(t, strings.HasSuffix(header, "-00"), "the stored unsampled decision is kept: %s", header)
+	case <-time.After(time.Second):
+		t.Fatal("webhook was not called")
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWorkerService_DefersDuringQuietHours(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"messageId": "external-123"})
+	}))
+	defer server.Close()
+
+	// quiet hours around the current time, in every recipient's timezone
+	now := time.Now().UTC()
+	quiet := now.Add(-time.Hour).Format("15:04") + "-" + now.Add(2*time.Hour).Format("15:04")
+	policy, err := service.ParseQuietHours("marketing="+quiet, "UTC")
+	require.NoError(t, err)
+
+	marketing := model.Message{ID: uuid.New(), To: "+1234567890", Content: "Sale!", Category: model.CategoryMarketing, Timezone: "UTC"}
+	transactional := model.Message{ID: uuid.New(), To: "+1234567890", Content: "Your code is 1234", Category: model.CategoryTransactional}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("ClaimPending", 2).Return([]model.Message{marketing, transactional}, nil)
+	mockRepo.On("Defer", marketing.ID, mock.MatchedBy(func(until time.Time) bool {
+		return until.After(now.Add(time.Hour)) && !until.After(now.Add(2*time.Hour))
+	})).Return(nil)
+	mockRepo.On("CompleteDelivery", transactional.ID, mock.Anything, model.StatusSent, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil)
+
+	svc := service.NewWorkerService(mockRepo, nil, &config.Config{WebhookUrl: server.URL, WorkerBatchSize: 2})
+	svc.QuietHours = policy
+
+	result := svc.ProcessMessages()
+	assert.Equal(t, 2, result.Claimed)
+	assert.Equal(t, 1, result.Deferred)
+	assert.Equal(t, 1, result.Sent)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWorkerService_SuppressesOptedOutRecipients(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"messageId": "external-123"})
+	}))
+	defer server.Close()
+
+	optedOut := model.Message{ID: uuid.New(), To: "+90 555 111 22 33", Content: "Sale!"}
+	allowed := model.Message{ID: uuid.New(), To: "+905559998877", Content: "Your code is 1234"}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("ClaimPending", 2).Return([]model.Message{optedOut, allowed}, nil)
+	mockRepo.On("Suppress", optedOut.ID).Return(nil)
+	mockRepo.On("CompleteDelivery", allowed.ID, mock.Anything, model.StatusSent, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil)
+
+	suppressions := new(MockSuppressionRepository)
+	suppressions.On("Suppressed", []string{"+905551112233", "+905559998877"}).Return(map[string]bool{"+905551112233": true}, nil)
+
+	svc := service.NewWorkerService(mockRepo, nil, &config.Config{WebhookUrl: server.URL, WorkerBatchSize: 2})
+	svc.Suppressions = service.NewSuppressionService(suppressions, "STOP", "START")
+
+	result := svc.ProcessMessages()
+	assert.Equal(t, 1, result.Suppressed)
+	assert.Equal(t, 1, result.Sent)
+	assert.Equal(t, int32(1), calls.Load())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWorkerService_SkipsDuplicatesWithinWindow(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"messageId": "external-123"})
+	}))
+	defer server.Close()
+
+	sentBefore := uuid.New()
+	sendingElsewhere := uuid.New()
+	repeat := model.Message{ID: uuid.New(), To: "+90 555 111 22 33", Content: "Your code is 1234"}
+	first := model.Message{ID: uuid.New(), To: "+905559998877", Content: "Hello"}
+	copyOfFirst := model.Message{ID: uuid.New(), To: "+905559998877", Content: "  Hello "}
+	copyInFlight := model.Message{ID: uuid.New(), To: "+905554443322", Content: "Hello"}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("ClaimPending", 4).Return([]model.Message{repeat, first, copyOfFirst, copyInFlight}, nil)
+	mockRepo.On("RecentlySent", mock.Anything, mock.MatchedBy(func(since time.Time) bool {
+		return time.Since(since) > 9*time.Minute && time.Since(since) < 11*time.Minute
+	})).Return(map[string]uuid.UUID{model.ContentHash("+905551112233", "Your code is 1234"): sentBefore}, nil)
+	// an older copy of the last message is being sent by another batch
+	mockRepo.On("InFlight", mock.Anything).Return(map[string]uuid.UUID{
+		model.ContentHash("+905559998877", "Hello"): first.ID,
+		model.ContentHash("+905554443322", "Hello"): sendingElsewhere,
+	}, nil)
+	mockRepo.On("MarkDuplicate", repeat.ID, sentBefore).Return(nil)
+	// copies of a message that is still being sent wait for its outcome
+	nearRecheck := mock.MatchedBy(func(until time.Time) bool {
+		return time.Until(until) > 50*time.Second && time.Until(until) <= time.Minute
+	})
+	mockRepo.On("Defer", copyOfFirst.ID, nearRecheck).Return(nil)
+	mockRepo.On("Defer", copyInFlight.ID, nearRecheck).Return(nil)
+	mockRepo.On("CompleteDelivery", first.ID, mock.Anything, model.StatusSent, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil)
+
+	svc := service.NewWorkerService(mockRepo, nil, &config.Config{WebhookUrl: server.URL, WorkerBatchSize: 4, DedupeWindow: 10 * time.Minute})
+
+	result := svc.ProcessMessages()
+	assert.Equal(t, 1, result.Duplicates)
+	assert.Equal(t, 2, result.Deferred)
+	assert.Equal(t, 1, result.Sent)
+	assert.Equal(t, int32(1), calls.Load())
+	mockRepo.AssertNotCalled(t, "MarkDuplicate", copyOfFirst.ID, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWorkerService_SendsCopyWhenOriginalFails(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"messageId": "external-123"})
+	}))
+	defer server.Close()
+
+	original := model.Message{ID: uuid.New(), To: "+905559998877", Content: "Hello"}
+	duplicate := model.Message{ID: uuid.New(), To: "+905559998877", Content: "Hello"}
+	hash := model.ContentHash(original.To, original.Content)
+
+	mockRepo := new(MockRepository)
+	svc := service.NewWorkerService(mockRepo, nil, &config.Config{WebhookUrl: server.URL, WorkerBatchSize: 2, DedupeWindow: 10 * time.Minute})
+
+	// the original is sent and fails; its copy is put back instead of ending as DUPLICATE
+	mockRepo.On("ClaimPending", 2).Return([]model.Message{original, duplicate}, nil).Once()
+	mockRepo.On("RecentlySent", mock.Anything, mock.Anything).Return(map[string]uuid.UUID{}, nil)
+	mockRepo.On("InFlight", []string{hash, hash}).Return(map[string]uuid.UUID{hash: original.ID}, nil).Once()
+	mockRepo.On("Defer", duplicate.ID, mock.Anything).Return(nil).Once()
+	mockRepo.On("CompleteDelivery", original.ID, mock.Anything, model.StatusFailed, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil).Once()
+
+	result := svc.ProcessMessages()
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 1, result.Deferred)
+
+	// claimed again, nothing with its content was sent, so the copy goes out
+	mockRepo.On("ClaimPending", 2).Return([]model.Message{duplicate}, nil).Once()
+	mockRepo.On("InFlight", []string{hash}).Return(map[string]uuid.UUID{hash: duplicate.ID}, nil).Once()
+	mockRepo.On("CompleteDelivery", duplicate.ID, mock.Anything, model.StatusSent, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil).Once()
+
+	result = svc.ProcessMessages()
+	assert.Equal(t, 1, result.Sent)
+	assert.Equal(t, int32(2), calls.Load())
+	mockRepo.AssertNotCalled(t, "MarkDuplicate", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+// MockQueue is a mock implementation of service.Queue
+type MockQueue struct {
+	mock.Mock
+}
+
+func (m *MockQueue) Enqueue(ctx context.Context, ids ...uuid.UUID) error {
+	return m.Called(ids).Error(0)
+}
+
+func (m *MockQueue) Claim(ctx context.Context, limit int) ([]model.Message, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]model.Message), args.Error(1)
+}
+
+func (m *MockQueue) Ack(ctx context.Context, ids ...uuid.UUID) error {
+	return m.Called(ids).Error(0)
+}
+
+func TestWorkerService_ClaimsFromQueueAndAcks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	msg := model.Message{ID: uuid.New(), To: "+1234567890", Content: "Hello"}
+	queue := new(MockQueue)
+	queue.On("Claim", 5).Return([]model.Message{msg}, nil)
+	queue.On("Ack", []uuid.UUID{msg.ID}).Return(nil) // failed sends are acknowledged too
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("CompleteDelivery", msg.ID, mock.Anything, model.StatusFailed, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil)
+
+	svc := service.NewWorkerService(mockRepo, nil, &config.Config{WebhookUrl: server.URL})
+	svc.Queue = queue
+
+	result := svc.ProcessBatch(5)
+	assert.Equal(t, 1, result.Failed)
+	queue.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "ClaimPending", mock.Anything)
+}
+
+func TestWorkerService_DropsOutcomeOfExpiredClaim(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"messageId": "external-123"})
+	}))
+	defer server.Close()
+
+	claimedAt := time.Now().Add(-time.Hour)
+	msg := model.Message{ID: uuid.New(), To: "+905551112233", Content: "Hello", ClaimedAt: &claimedAt}
+	mockRepo := new(MockRepository)
+	mockRepo.On("ClaimPending", 1).Return([]model.Message{msg}, nil)
+	// the reaper released the message while the webhook call was running
+	mockRepo.On("CompleteDelivery", msg.ID, &claimedAt, model.StatusSent, mock.AnythingOfType("time.Duration"), "external-123").Return(repository.ErrConflict)
+
+	svc := service.NewWorkerService(mockRepo, nil, &config.Config{WebhookUrl: server.URL, WorkerBatchSize: 1})
+	svc.Updates = service.NewBroadcaster(10)
+	listener, _ := svc.Updates.Subscribe(service.UpdateFilter{Status: model.StatusSent}, 0)
+	defer listener.Close()
+
+	svc.ProcessMessages()
+	mockRepo.AssertExpectations(t)
+	select {
+	case u := <-listener.C:
+		t.Fatalf("announced %s for a message it no longer holds", u.Status)
+	default:
+	}
+}
+
+func TestLeaseReaper_ReleasesExpiredClaims(t *testing.T) {
+	mockRepo := new(MockRepository)
+	queue := new(MockQueue)
+	updates := service.NewBroadcaster(10)
+	listener, _ := updates.Subscribe(service.UpdateFilter{}, 0)
+	defer listener.Close()
+
+	reaper := service.NewLeaseReaper(mockRepo, 10*time.Minute)
+	reaper.Queue, reaper.Updates = queue, updates
+
+	stranded := []model.Message{{ID: uuid.New(), To: "+905551112233"}, {ID: uuid.New(), To: "+905551112234"}}
+	mockRepo.On("ReleaseExpired", mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= 10*time.Minute && time.Since(before) < 11*time.Minute
+	}), 500).Return(stranded, nil).Once()
+	queue.On("Enqueue", []uuid.UUID{stranded[0].ID, stranded[1].ID}).Return(nil)
+
+	released, err := reaper.ReapOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, released)
+	u := <-listener.C
+	assert.Equal(t, model.StatusProcessing, u.From)
+	assert.Equal(t, model.StatusPending, u.Status)
+
+	// nothing expired: nothing is announced
+	mockRepo.On("ReleaseExpired", mock.Anything, 500).Return([]model.Message{}, nil).Once()
+	released, err = reaper.ReapOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, released)
+	queue.AssertNumberOfCalls(t, "Enqueue", 1)
+}
@@ -0,0 +1,115 @@
+package cron_test
+
+import (
+	"insider-assessment/pkg/cron"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustTime(t *testing.T, value string, loc *time.Location) time.Time {
+	t.Helper()
+	ts, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	require.NoError(t, err)
+	return ts
+}
+
+func TestParse_Next(t *testing.T) {
+	cases := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"*/15 * * * *", "2024-03-04 10:07", "2024-03-04 10:15"},
+		{"0 9 * * mon-fri", "2024-03-08 09:00", "2024-03-11 09:00"}, // Friday -> Monday
+		{"30 8,12 * * *", "2024-03-04 08:30", "2024-03-04 12:30"},
+		{"0 0 1 */3 *", "2024-02-10 00:00", "2024-04-01 00:00"},
+		{"0 12 13 * 5", "2024-09-01 00:00", "2024-09-06 12:00"}, // day-of-month OR day-of-week
+		{"0 0 * * 7", "2024-03-04 00:00", "2024-03-10 00:00"},   // 7 is Sunday
+		{"@hourly", "2024-03-04 10:59", "2024-03-04 11:00"},
+	}
+	for _, tc := range cases {
+		s, err := cron.Parse(tc.expr)
+		require.NoError(t, err, tc.expr)
+		got := s.Next(mustTime(t, tc.from, time.UTC))
+		assert.Equal(t, mustTime(t, tc.want, time.UTC), got, tc.expr)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		_, err := cron.Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestParse_NeverMatches(t *testing.T) {
+	s, err := cron.Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(time.Now()).IsZero())
+}
+
+func TestWindow_Contains(t *testing.T) {
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	require.NoError(t, err)
+
+	w, err := cron.ParseWindow("Mon-Fri 09:00-21:00 Europe/Istanbul")
+	require.NoError(t, err)
+
+	assert.True(t, w.Contains(mustTime(t, "2024-03-04 09:00", istanbul)))  // Monday opening
+	assert.False(t, w.Contains(mustTime(t, "2024-03-04 21:00", istanbul))) // end is exclusive
+	assert.False(t, w.Contains(mustTime(t, "2024-03-09 12:00", istanbul))) // Saturday
+	// 06:30 UTC is 09:30 in Istanbul
+	assert.True(t, w.Contains(mustTime(t, "2024-03-04 06:30", time.UTC)))
+}
+
+func TestWindow_SpansMidnight(t *testing.T) {
+	w, err := cron.ParseWindow("Fri 22:00-06:00")
+	require.NoError(t, err)
+
+	assert.True(t, w.Contains(mustTime(t, "2024-03-08 23:00", time.UTC)))  // Friday night
+	assert.True(t, w.Contains(mustTime(t, "2024-03-09 05:59", time.UTC)))  // still Friday's window
+	assert.False(t, w.Contains(mustTime(t, "2024-03-10 01:00", time.UTC))) // Saturday's night isn't
+}
+
+func TestWindow_NextOpen(t *testing.T) {
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	require.NoError(t, err)
+
+	w, err := cron.ParseWindow("Mon-Fri 09:00-21:00 Europe/Istanbul")
+	require.NoError(t, err)
+
+	friday := mustTime(t, "2024-03-08 22:00", istanbul)
+	assert.True(t, mustTime(t, "2024-03-11 09:00", istanbul).Equal(w.NextOpen(friday)))
+
+	inside := mustTime(t, "2024-03-08 10:00", istanbul)
+	assert.True(t, inside.Equal(w.NextOpen(inside)))
+}
+
+func TestParseWindow_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "Mon-Fri", "Mon-Fri 09:00", "Xyz 09:00-10:00", "09:00-09:00", "09:00-25:00", "09:00-10:00 Mars/Olympus"} {
+		_, err := cron.ParseWindow(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestWindow_NextClose(t *testing.T) {
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	require.NoError(t, err)
+
+	quiet, err := cron.ParseWindow("21:00-09:00")
+	require.NoError(t, err)
+	quiet = quiet.In(istanbul)
+
+	assert.True(t, mustTime(t, "2024-03-05 09:00", istanbul).Equal(quiet.NextClose(mustTime(t, "2024-03-04 23:30", istanbul))))
+	assert.True(t, mustTime(t, "2024-03-05 09:00", istanbul).Equal(quiet.NextClose(mustTime(t, "2024-03-05 02:00", istanbul))))
+
+	outside := mustTime(t, "2024-03-05 12:00", istanbul)
+	assert.True(t, outside.Equal(quiet.NextClose(outside)))
+
+	weekend, err := cron.ParseWindow("Sat-Sun 00:00-24:00")
+	require.NoError(t, err)
+	assert.True(t, mustTime(t, "2024-03-11 00:00", time.UTC).Equal(weekend.NextClose(mustTime(t, "2024-03-09 10:00", time.UTC))))
+}
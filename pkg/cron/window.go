@@ -0,0 +1,200 @@
+package cron
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // the runtime image ships without a zoneinfo database
+)
+
+// Window is a recurring time-of-day window on selected weekdays in a fixed timezone,
+// e.g. "Mon-Fri 09:00-21:00 Europe/Istanbul". The end is exclusive; an end before the
+// start spans midnight and belongs to the day it starts on.
+type Window struct {
+	expr     string
+	days     [7]bool
+	start    time.Duration // offset from local midnight
+	end      time.Duration
+	location *time.Location
+}
+
+// ParseWindow reads "[days] HH:MM-HH:MM [timezone]". Days are comma separated names or
+// ranges (Mon-Fri,Sun) and default to every day; the timezone defaults to UTC.
+func ParseWindow(expr string) (*Window, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 0 || len(fields) > 3 {
+		return nil, fmt.Errorf("window: expected \"[days] HH:MM-HH:MM [timezone]\", got %q", expr)
+	}
+
+	w := &Window{expr: expr, location: time.UTC}
+
+	// the hours field is the one containing a colon; days come before it, the zone after it
+	hoursAt := -1
+	for i, f := range fields {
+		if strings.Contains(f, ":") {
+			hoursAt = i
+			break
+		}
+	}
+	if hoursAt < 0 || hoursAt > 1 {
+		return nil, fmt.Errorf("window: missing HH:MM-HH:MM in %q", expr)
+	}
+
+	if hoursAt == 1 {
+		if err := w.parseDays(fields[0]); err != nil {
+			return nil, err
+		}
+	} else {
+		for i := range w.days {
+			w.days[i] = true
+		}
+	}
+
+	if err := w.parseHours(fields[hoursAt]); err != nil {
+		return nil, err
+	}
+
+	if rest := fields[hoursAt+1:]; len(rest) == 1 {
+		loc, err := time.LoadLocation(rest[0])
+		if err != nil {
+			return nil, fmt.Errorf("window: unknown timezone %q", rest[0])
+		}
+		w.location = loc
+	} else if len(rest) > 1 {
+		return nil, fmt.Errorf("window: unexpected %q", strings.Join(rest[1:], " "))
+	}
+	return w, nil
+}
+
+// String returns the expression the window was parsed from
+func (w *Window) String() string {
+	return w.expr
+}
+
+// Location returns the timezone the window is evaluated in
+func (w *Window) Location() *time.Location {
+	return w.location
+}
+
+// Contains reports whether t falls inside the window
+func (w *Window) Contains(t time.Time) bool {
+	t = t.In(w.location)
+	offset := w.clock(t)
+
+	if w.start < w.end {
+		return w.days[t.Weekday()] && offset >= w.start && offset < w.end
+	}
+	// spans midnight: the evening part belongs to today, the morning part to yesterday
+	if offset >= w.start {
+		return w.days[t.Weekday()]
+	}
+	if offset < w.end {
+		return w.days[(t.Weekday()+6)%7]
+	}
+	return false
+}
+
+// In returns a copy of the window evaluated in loc, e.g. quiet hours in the recipient's timezone
+func (w *Window) In(loc *time.Location) *Window {
+	c := *w
+	c.location = loc
+	return &c
+}
+
+// NextClose returns t if it is outside the window, otherwise the time the window closes,
+// following adjacent windows such as "Sat-Sun 00:00-24:00" through to their end.
+// It returns the zero time for a window that never closes.
+func (w *Window) NextClose(t time.Time) time.Time {
+	for i := 0; i <= 7; i++ {
+		if !w.Contains(t) {
+			return t
+		}
+
+		local := t.In(w.location)
+		day := local.Day()
+		if w.start >= w.end && w.clock(local) >= w.start {
+			day++ // the evening part of a window spanning midnight closes tomorrow
+		}
+		t = time.Date(local.Year(), local.Month(), day, int(w.end/time.Hour), int(w.end%time.Hour/time.Minute), 0, 0, w.location)
+	}
+	return time.Time{}
+}
+
+// NextOpen returns t if it is inside the window, otherwise the next time the window opens.
+// It returns the zero time for a window that never opens.
+func (w *Window) NextOpen(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+
+	local := t.In(w.location)
+	for i := 0; i <= 7; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 0, 0, 0, 0, w.location)
+		if !w.days[day.Weekday()] {
+			continue
+		}
+		open := time.Date(day.Year(), day.Month(), day.Day(), int(w.start/time.Hour), int(w.start%time.Hour/time.Minute), 0, 0, w.location)
+		if open.After(t) {
+			return open
+		}
+	}
+	return time.Time{}
+}
+
+// clock returns the wall clock time of t as an offset from midnight, so DST changes don't shift the window
+func (w *Window) clock(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+func (w *Window) parseDays(spec string) error {
+	for _, part := range strings.Split(spec, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		lo, ok := weekdayNames[strings.ToLower(bounds[0])]
+		if !ok {
+			return fmt.Errorf("window: invalid day %q", bounds[0])
+		}
+		hi := lo
+		if len(bounds) == 2 {
+			if hi, ok = weekdayNames[strings.ToLower(bounds[1])]; !ok {
+				return fmt.Errorf("window: invalid day %q", bounds[1])
+			}
+		}
+		// ranges may wrap around the week, e.g. Sat-Sun or Fri-Mon
+		for d := lo; ; d = (d + 1) % 7 {
+			w.days[d] = true
+			if d == hi {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (w *Window) parseHours(spec string) error {
+	bounds := strings.SplitN(spec, "-", 2)
+	if len(bounds) != 2 {
+		return fmt.Errorf("window: invalid hours %q", spec)
+	}
+
+	var err error
+	if w.start, err = parseClock(bounds[0]); err != nil {
+		return err
+	}
+	if w.end, err = parseClock(bounds[1]); err != nil {
+		return err
+	}
+	if w.start == w.end {
+		return fmt.Errorf("window: empty hours %q", spec)
+	}
+	return nil
+}
+
+// parseClock reads HH:MM; 24:00 is accepted as the end of the day
+func parseClock(s string) (time.Duration, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || len(s) != 5 ||
+		h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("window: invalid time %q", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
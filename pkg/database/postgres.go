@@ -3,9 +3,11 @@ package database
 import (
 	"fmt"
 	"insider-assessment/internal/config"
+	"insider-assessment/pkg/metrics"
 	"log/slog"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -48,6 +50,9 @@ func NewPostgresDB(cfg *config.Config) (*gorm.DB, error) {
 
 	sqlDB.SetConnMaxLifetime(time.Hour) // 1 hour after a connection may be reused
 
+	// expose pool usage (open/idle/in-use connections, wait counts) on /metrics
+	metrics.Register(collectors.NewDBStatsCollector(sqlDB, cfg.DBName))
+
 	slog.Info("Successfully connected to PostgreSQL with Connection Pooling")
 	return db, nil
 }
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"insider-assessment/internal/config"
+	"insider-assessment/pkg/tracing"
 	"log/slog"
 
 	"github.com/go-redis/redis/v8"
@@ -20,6 +21,9 @@ func NewRedisClient(cfg *config.Config) (*redis.Client, error) {
 		return nil, err
 	}
 
+	// commands issued with a traced context show up as child spans
+	rdb.AddHook(tracing.RedisHook{})
+
 	slog.Info("Successfully connected to Redis")
 	return rdb, nil
 }
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ChannelSMS is the only delivery channel today; the label keeps dashboards stable when more are added
+const ChannelSMS = "sms"
+
+const namespace = "insider"
+
+var (
+	// MessagesEnqueued counts messages stored as PENDING
+	MessagesEnqueued = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_enqueued_total",
+		Help:      "Messages added to the queue.",
+	}, []string{"channel"})
+
+	// MessagesDelivered counts webhook outcomes by final status (SENT or FAILED)
+	MessagesDelivered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_delivered_total",
+		Help:      "Messages processed by the worker, by resulting status.",
+	}, []string{"status", "channel"})
+
+	// WebhookLatency observes the webhook round trip in seconds
+	WebhookLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "webhook_request_duration_seconds",
+		Help:      "Latency of outbound webhook calls.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"channel"})
+)
+
+// RegisterGauge exposes a value computed at scrape time, e.g. queue depth
+func RegisterGauge(name, help string, fn func() float64) {
+	Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// Register adds a collector to the default registry, tolerating duplicates
+func Register(c prometheus.Collector) {
+	if err := prometheus.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if !errors.As(err, &already) {
+			slog.Error("failed to register metrics collector", "error", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package phone
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultCountryCode is the calling code of numbers written in national format ("0555...")
+var defaultCountryCode string
+
+// SetDefaultCountryCode sets the calling code (e.g. "90") Normalize assumes for numbers in
+// national format. Call it once at startup, before numbers are normalized; "" keeps such
+// numbers as they are.
+func SetDefaultCountryCode(code string) error {
+	code = strings.TrimPrefix(strings.TrimSpace(code), "+")
+	if code != "" && (len(code) > 3 || strings.Trim(code, "0123456789") != "" || code[0] == '0') {
+		return fmt.Errorf("invalid country calling code %q", code)
+	}
+	defaultCountryCode = code
+	return nil
+}
+
+// Normalize turns a number into E.164 form so that every way of writing it compares equal:
+// "+90 (555) 111-22-33", "00905551112233", "905551112233" and, with a default country code
+// of 90, "0555 111 22 33" all become "+905551112233". It strips the formatting people type
+// (spaces, dashes, dots and parentheses), turns a leading "00" into '+', and prefixes other
+// numbers of digits with '+', as they can only be international numbers without it. A
+// national number (leading '0') stays as it is without a default country code. Other
+// characters are kept: Normalize doesn't validate.
+func Normalize(number string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(number) {
+		switch r {
+		case ' ', '-', '.', '(', ')', '\t':
+			continue
+		}
+		b.WriteRune(r)
+	}
+	n := b.String()
+	if rest, ok := strings.CutPrefix(n, "00"); ok && rest != "" {
+		return "+" + rest
+	}
+	if n == "" || strings.Trim(n, "0123456789") != "" {
+		return n
+	}
+	if national, ok := strings.CutPrefix(n, "0"); ok {
+		if defaultCountryCode == "" || national == "" {
+			return n
+		}
+		return "+" + defaultCountryCode + national
+	}
+	return "+" + n
+}
@@ -0,0 +1,39 @@
+package phone_test
+
+import (
+	"insider-assessment/pkg/phone"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"+90 (555) 111-22-33": "+905551112233",
+		"00905551112233":      "+905551112233",
+		" 0555.111.22.33 ":    "05551112233",
+		"+905551112233":       "+905551112233",
+		"905551112233":        "+905551112233",
+		"90 555 111 22 33":    "+905551112233",
+		"abc":                 "abc",
+		"":                    "",
+	}
+	for number, want := range cases {
+		assert.Equal(t, want, phone.Normalize(number), number)
+	}
+}
+
+func TestNormalizeWithDefaultCountryCode(t *testing.T) {
+	require.NoError(t, phone.SetDefaultCountryCode("+90"))
+	defer phone.SetDefaultCountryCode("")
+
+	for _, number := range []string{"0555 111 22 33", "905551112233", "+905551112233", "00905551112233"} {
+		assert.Equal(t, "+905551112233", phone.Normalize(number), number)
+	}
+	assert.Equal(t, "0", phone.Normalize("0"))
+
+	for _, invalid := range []string{"0090", "1234", "9a"} {
+		assert.Error(t, phone.SetDefaultCountryCode(invalid), invalid)
+	}
+}
@@ -0,0 +1,41 @@
+// Package phone derives recipient properties from E.164 numbers.
+package phone
+
+import "strings"
+
+// countryTimezones maps E.164 country calling codes to the IANA timezone used nationwide.
+// Countries spanning several timezones (e.g. +1, +7, +55, +61) are deliberately left out:
+// guessing one of them could put a message in the middle of the recipient's night.
+var countryTimezones = map[string]string{
+	"20": "Africa/Cairo", "27": "Africa/Johannesburg", "30": "Europe/Athens", "31": "Europe/Amsterdam",
+	"32": "Europe/Brussels", "33": "Europe/Paris", "34": "Europe/Madrid", "36": "Europe/Budapest",
+	"39": "Europe/Rome", "40": "Europe/Bucharest", "41": "Europe/Zurich", "43": "Europe/Vienna",
+	"44": "Europe/London", "45": "Europe/Copenhagen", "46": "Europe/Stockholm", "47": "Europe/Oslo",
+	"48": "Europe/Warsaw", "49": "Europe/Berlin", "51": "America/Lima", "54": "America/Argentina/Buenos_Aires",
+	"57": "America/Bogota", "60": "Asia/Kuala_Lumpur", "63": "Asia/Manila", "64": "Pacific/Auckland",
+	"65": "Asia/Singapore", "66": "Asia/Bangkok", "81": "Asia/Tokyo", "82": "Asia/Seoul",
+	"84": "Asia/Ho_Chi_Minh", "86": "Asia/Shanghai", "90": "Europe/Istanbul", "91": "Asia/Kolkata",
+	"92": "Asia/Karachi", "212": "Africa/Casablanca", "234": "Africa/Lagos", "254": "Africa/Nairobi",
+	"351": "Europe/Lisbon", "353": "Europe/Dublin", "358": "Europe/Helsinki", "359": "Europe/Sofia",
+	"370": "Europe/Vilnius", "371": "Europe/Riga", "372": "Europe/Tallinn", "380": "Europe/Kyiv",
+	"381": "Europe/Belgrade", "385": "Europe/Zagreb", "420": "Europe/Prague", "421": "Europe/Bratislava",
+	"966": "Asia/Riyadh", "971": "Asia/Dubai", "972": "Asia/Jerusalem", "974": "Asia/Qatar",
+	"965": "Asia/Kuwait", "973": "Asia/Bahrain", "994": "Asia/Baku", "995": "Asia/Tbilisi",
+}
+
+// Timezone infers the recipient's timezone from the country code of an E.164 number
+// such as "+905551112233". It returns "" when the number has no leading '+' (the country
+// code can't be told apart from a national number) or the country spans several timezones.
+func Timezone(number string) string {
+	digits, ok := strings.CutPrefix(strings.TrimSpace(number), "+")
+	if !ok {
+		return ""
+	}
+	// calling codes are prefix-free, so at most one length matches
+	for n := 1; n <= 3 && n <= len(digits); n++ {
+		if tz, ok := countryTimezones[digits[:n]]; ok {
+			return tz
+		}
+	}
+	return ""
+}
@@ -0,0 +1,22 @@
+package phone_test
+
+import (
+	"insider-assessment/pkg/phone"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimezone(t *testing.T) {
+	cases := map[string]string{
+		"+905551112233": "Europe/Istanbul",
+		"+447911123456": "Europe/London",
+		"+971501234567": "Asia/Dubai",
+		"+12025550123":  "", // +1 spans several timezones
+		"905551112233":  "", // no '+', country code is ambiguous
+		"":              "",
+	}
+	for number, want := range cases {
+		assert.Equal(t, want, phone.Timezone(number), number)
+	}
+}